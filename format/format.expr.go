@@ -0,0 +1,49 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// layout renders e indented by indent. Expressions which do not fit in the
+// line width are split at and/or operators, one operand per line, and
+// parenthesised operands or loop bodies are broken down one level deeper.
+func layout(e *grammar.Expr, indent string, opts Options) string {
+	line := indent + e.String()
+	if opts.LineWidth <= 0 || width(line) <= opts.LineWidth {
+		return line
+	}
+	inner := indent + opts.Indent
+	switch {
+	case e.Kind == grammar.ExprBinary && (e.Op == "and" || e.Op == "or"):
+		operands := chain(e, e.Op)
+		lines := make([]string, len(operands))
+		for i, o := range operands {
+			if o.Precedence() <= e.Precedence() {
+				o = &grammar.Expr{Kind: grammar.ExprParen, Args: []*grammar.Expr{o}}
+			}
+			lines[i] = layout(o, indent, opts)
+			if i < len(operands)-1 {
+				lines[i] += " " + e.Op
+			}
+		}
+		return strings.Join(lines, "\n")
+	case e.Kind == grammar.ExprParen:
+		return indent + "(\n" + layout(e.Args[0], inner, opts) + "\n" + indent + ")"
+	case e.Kind == grammar.ExprUnary && e.Op == "not" && e.Args[0].Kind == grammar.ExprParen:
+		return indent + "not (\n" + layout(e.Args[0].Args[0], inner, opts) + "\n" + indent + ")"
+	case e.Kind == grammar.ExprForOf || e.Kind == grammar.ExprForIn:
+		header := strings.TrimSuffix(line, " : ("+e.Args[2].String()+")")
+		return header + " : (\n" + layout(e.Args[2], inner, opts) + "\n" + indent + ")"
+	}
+	return line
+}
+
+// chain flattens a sequence of the same and/or operator
+func chain(e *grammar.Expr, op string) []*grammar.Expr {
+	if e.Kind != grammar.ExprBinary || e.Op != op {
+		return []*grammar.Expr{e}
+	}
+	return append(chain(e.Args[0], op), chain(e.Args[1], op)...)
+}
@@ -0,0 +1,171 @@
+// Package format renders parsed Yara rules back into canonical Yara source.
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// Options tunes the output of the formatter
+type Options struct {
+	Indent    string // indentation unit
+	LineWidth int    // long hex strings and conditions are wrapped to fit it
+}
+
+// DefaultOptions are the options used by yago fmt
+var DefaultOptions = Options{
+	Indent:    "\t",
+	LineWidth: 100,
+}
+
+// tabWidth is the width of a tab when measuring lines
+const tabWidth = 4
+
+// Source parses src and returns it formatted
+func Source(name string, src []byte, opts Options) ([]byte, error) {
+	p := grammar.New(name)
+	if err := p.Parse(string(src)); err != nil {
+		return nil, err
+	}
+	return []byte(File(p, opts)), nil
+}
+
+// File renders a parsed file: the imports and the rules, between the
+// comments of the ruleset
+func File(p *grammar.Parser, opts Options) string {
+	var buf bytes.Buffer
+	if p.Comments != nil && len(p.Comments.Leading) > 0 {
+		writeComments(&buf, p.Comments.Leading, "")
+		buf.WriteString("\n")
+	}
+	buf.WriteString(Ruleset(p.Imports, p.Rules, opts))
+	if p.Comments != nil && len(p.Comments.Trailing) > 0 {
+		buf.WriteString("\n")
+		writeComments(&buf, p.Comments.Trailing, "")
+	}
+	return buf.String()
+}
+
+// Ruleset renders the imports followed by the rules
+func Ruleset(imports []string, rules []grammar.RuleDef, opts Options) string {
+	var buf bytes.Buffer
+	for _, imp := range imports {
+		buf.WriteString("import \"" + imp + "\"\n")
+	}
+	for i, rule := range rules {
+		if i > 0 || len(imports) > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(Rule(rule, opts))
+	}
+	return buf.String()
+}
+
+// Rule renders a single rule
+func Rule(rule grammar.RuleDef, opts Options) string {
+	var buf bytes.Buffer
+	in1 := opts.Indent
+	in2 := in1 + opts.Indent
+	comments := rule.Comments
+	if comments == nil {
+		comments = &grammar.Comments{}
+	}
+
+	writeComments(&buf, comments.Leading, "")
+	if rule.Private {
+		buf.WriteString("private ")
+	}
+	if rule.Global {
+		buf.WriteString("global ")
+	}
+	buf.WriteString("rule " + rule.Name)
+	if len(rule.Tags) > 0 {
+		buf.WriteString(" : " + strings.Join(rule.Tags, " "))
+	}
+	buf.WriteString(" {\n")
+	writeComments(&buf, comments.Inner, in1)
+
+	if len(rule.Meta) > 0 {
+		buf.WriteString(in1 + "meta:\n")
+		for _, m := range rule.Meta {
+			if m.Comments != nil {
+				writeComments(&buf, m.Comments.Leading, in2)
+			}
+			buf.WriteString(in2 + m.Key + " = " + MetaValue(m) + trailing(m.Comments) + "\n")
+		}
+	}
+	if len(rule.Strings) > 0 {
+		buf.WriteString(in1 + "strings:\n")
+		for _, str := range rule.Strings {
+			if str.Comments != nil {
+				writeComments(&buf, str.Comments.Leading, in2)
+			}
+			buf.WriteString(String(str, in2, opts) + trailing(str.Comments) + "\n")
+		}
+	}
+	buf.WriteString(in1 + "condition:\n")
+	cond := comments.Condition
+	if cond == nil {
+		cond = &grammar.Comments{}
+	}
+	writeComments(&buf, cond.Leading, in2)
+	if rule.ConditionAST != nil {
+		buf.WriteString(layout(rule.ConditionAST, in2, opts) + trailing(cond) + "\n")
+	} else {
+		buf.WriteString(in2 + rule.Condition + trailing(cond) + "\n")
+	}
+	writeComments(&buf, cond.Inner, in2)
+	buf.WriteString("}" + trailing(comments) + "\n")
+	return buf.String()
+}
+
+// writeComments writes each comment on lines of its own, indented by indent
+func writeComments(buf *bytes.Buffer, comments []string, indent string) {
+	for _, c := range comments {
+		buf.WriteString(indent + c + "\n")
+	}
+}
+
+// trailing returns the trailing comments to write at the end of a line
+func trailing(c *grammar.Comments) string {
+	if c == nil || len(c.Trailing) == 0 {
+		return ""
+	}
+	return " " + strings.Join(c.Trailing, " ")
+}
+
+// MetaValue renders a meta value with its original type
+func MetaValue(m grammar.MetaDef) string {
+	if m.Typ == grammar.MetaInt || m.Typ == grammar.MetaBool {
+		return m.Value
+	}
+	return "\"" + m.Value + "\""
+}
+
+// String renders a string definition indented by indent
+func String(str grammar.StringDef, indent string, opts Options) string {
+	line := indent + str.Name + " = "
+	switch str.Typ {
+	case grammar.StringHex:
+		line += hexString(str, indent, width(line), opts)
+	case grammar.StringRegex:
+		if str.Body != "" {
+			line += "/" + str.Body + "/" + str.Flags
+		} else {
+			line += str.Value
+		}
+	default:
+		line += "\"" + str.Value + "\""
+	}
+	for _, m := range str.Modifiers {
+		line += " " + m.String()
+	}
+	return line
+}
+
+// width returns the width of s, counting tabs as tabWidth columns
+func width(s string) int {
+	return len(s) + strings.Count(s, "\t")*(tabWidth-1)
+}
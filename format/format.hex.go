@@ -0,0 +1,43 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// hexString renders a hex string as space separated tokens, wrapping it
+// over several lines when it does not fit in the line width. column is
+// where the opening curly bracket goes.
+func hexString(str grammar.StringDef, indent string, column int, opts Options) string {
+	hex := str.Hex
+	if hex == nil {
+		var err error
+		if hex, err = grammar.ParseHex(str.Value); err != nil {
+			return str.Value
+		}
+	}
+	tokens := make([]string, len(hex))
+	for i, t := range hex {
+		tokens[i] = t.String()
+	}
+	line := "{ " + strings.Join(tokens, " ") + " }"
+	if opts.LineWidth <= 0 || column+len(line) <= opts.LineWidth {
+		return line
+	}
+
+	inner := indent + opts.Indent
+	r := "{\n"
+	cur := inner
+	for _, t := range tokens {
+		if cur != inner && width(cur)+1+len(t) > opts.LineWidth {
+			r += cur + "\n"
+			cur = inner
+		}
+		if cur != inner {
+			cur += " "
+		}
+		cur += t
+	}
+	return r + cur + "\n" + indent + "}"
+}
@@ -0,0 +1,42 @@
+package grammar
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchRuleset returns the ruleset the lexer and parser benchmarks share
+func benchRuleset(b *testing.B) string {
+	src, err := ioutil.ReadFile(filepath.Join("..", "testdata", "bench.yar"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(src)
+}
+
+func BenchmarkParse(b *testing.B) {
+	input := benchRuleset(b)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := New("").Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseReader(b *testing.B) {
+	input := benchRuleset(b)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := ParseReader(strings.NewReader(input), func(RuleDef) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
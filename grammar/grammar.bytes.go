@@ -0,0 +1,38 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WideBytes returns the bytes of a text string as the wide modifier looks
+// for them, each byte followed by a zero
+func (s StringDef) WideBytes() []byte {
+	return Wide(s.Bytes)
+}
+
+// HexDump returns the bytes of a text string in hex, such as 4D 5A 90
+func (s StringDef) HexDump() string {
+	return HexDump(s.Bytes)
+}
+
+// Wide interleaves b with zeros, as Yara encodes wide strings
+func Wide(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	res := make([]byte, 0, 2*len(b))
+	for _, c := range b {
+		res = append(res, c, 0)
+	}
+	return res
+}
+
+// HexDump returns b as upper case hex bytes separated by spaces
+func HexDump(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,209 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// Comments holds the comments written around a ruleset, a rule, a meta entry
+// or a string, markers included. Leading comments are on the lines before
+// it and trailing ones follow its last item on the same line.
+type Comments struct {
+	Leading  []string `json:"leading,omitempty"`
+	Trailing []string `json:"trailing,omitempty"`
+	Inner    []string `json:"inner,omitempty"` // inside a rule, away from its meta, strings and condition
+
+	// Condition holds the comments of the condition of a rule: the leading
+	// ones are written before its end, the trailing ones on its last line
+	// and the inner ones on the lines after it.
+	Condition *Comments `json:"condition,omitempty"`
+}
+
+// comment is a comment read by the parser, until it is attached
+type comment struct {
+	text       string
+	start      int  // offset of the comment
+	line       int  // line where the comment starts
+	endLine    int  // line where the comment ends
+	first      bool // no item comes before it
+	trailing   bool // on the line of the item before it
+	prevEnd    int  // offset where the item before it ends
+	next       int  // offset where the item after it starts
+	blankAfter bool // a blank line follows it
+}
+
+// fetch reads the next item from the lexer, keeping the comments found
+// before it. Lexical errors are returned instead of raised.
+func (p *Parser) fetch() (lexic.Item, *ParseError) {
+	var comments []*comment
+	item, err := p.Lex.Next()
+	for item.Kind == lexic.ItemComment {
+		text := strings.Replace(strings.TrimRight(item.Value, "\r\n"), "\r\n", "\n", -1)
+		comments = append(comments, &comment{
+			text:     text,
+			start:    item.Pos,
+			line:     item.Line,
+			endLine:  item.Line + strings.Count(text, "\n"),
+			first:    !p.fetched,
+			trailing: p.fetched && item.Line == p.prevLine,
+			prevEnd:  p.prevEnd,
+		})
+		item, err = p.Lex.Next()
+	}
+	start, end := item.Span()
+	for i, c := range comments {
+		c.next = start
+		nextLine := item.Line
+		if i+1 < len(comments) {
+			nextLine = comments[i+1].line
+		}
+		c.blankAfter = nextLine-c.endLine >= 2
+	}
+	p.comments = append(p.comments, comments...)
+	p.fetched, p.prevEnd, p.prevLine = true, end, item.Line
+
+	if lexErr, ok := err.(*lexic.Error); ok {
+		return item, p.newError(LexicalError, item, lexErr.Msg)
+	}
+	return item, nil
+}
+
+// lookAhead reads the next item, if not read yet, so that the comments
+// following the last item on its line are known. A lexical error is raised
+// when the item is consumed.
+func (p *Parser) lookAhead() {
+	if p.peekCount > 0 {
+		return
+	}
+	p.token[0], p.lexErr = p.fetch()
+	p.peekCount = 1
+}
+
+// raiseLexErr raises the lexical error found by lookAhead, if any
+func (p *Parser) raiseLexErr() {
+	if err := p.lexErr; err != nil {
+		p.lexErr = nil
+		panic(err)
+	}
+}
+
+// attachComments attaches the comments read up to the end of rule to it,
+// its meta entries and its strings. Comments before the rule which do not
+// lead to it, and the header of the file, go to the ruleset.
+func (p *Parser) attachComments(rule *RuleDef) {
+	start, end := rule.Span.Start.Offset, rule.Span.End.Offset
+	header := -1 // last comment of the header of the file
+	for i, c := range p.comments {
+		if c.start < start && c.first && c.blankAfter {
+			header = i
+		}
+	}
+
+	var rest []*comment
+	for i, c := range p.comments {
+		switch {
+		case c.start < start:
+			if c.next == start && !c.trailing && i > header {
+				rule.Comments = addLeading(rule.Comments, c.text)
+			} else {
+				p.rulesetComment(c)
+			}
+		case c.start < end:
+			attachInner(rule, c)
+		case c.trailing && c.prevEnd == end:
+			rule.Comments = addTrailing(rule.Comments, c.text)
+		default:
+			rest = append(rest, c)
+		}
+	}
+	p.comments = rest
+	p.ruleSeen = true
+}
+
+// attachInner attaches a comment found inside rule to the meta entry or the
+// string it follows on the same line or it leads to, to the condition when
+// it comes after the condition keyword, or to the rule.
+func attachInner(rule *RuleDef, c *comment) {
+	for i, m := range rule.Meta {
+		if m.Span == nil {
+			continue
+		}
+		if c.trailing && m.Span.End.Offset == c.prevEnd {
+			rule.Meta[i].Comments = addTrailing(m.Comments, c.text)
+			return
+		}
+		if m.Span.Start.Offset == c.next {
+			rule.Meta[i].Comments = addLeading(m.Comments, c.text)
+			return
+		}
+	}
+	for i, str := range rule.Strings {
+		if str.Span == nil {
+			continue
+		}
+		if c.trailing && str.Span.End.Offset == c.prevEnd {
+			rule.Strings[i].Comments = addTrailing(str.Comments, c.text)
+			return
+		}
+		if str.Span.Start.Offset == c.next {
+			rule.Strings[i].Comments = addLeading(str.Comments, c.text)
+			return
+		}
+	}
+	if rule.Comments == nil {
+		rule.Comments = &Comments{}
+	}
+	if span := rule.ConditionSpan; span != nil && c.next >= span.Start.Offset {
+		cond := rule.Comments.Condition
+		switch {
+		case c.start < span.End.Offset:
+			cond = addLeading(cond, c.text)
+		case c.trailing && c.prevEnd == span.End.Offset:
+			cond = addTrailing(cond, c.text)
+		default:
+			if cond == nil {
+				cond = &Comments{}
+			}
+			cond.Inner = append(cond.Inner, c.text)
+		}
+		rule.Comments.Condition = cond
+		return
+	}
+	rule.Comments.Inner = append(rule.Comments.Inner, c.text)
+}
+
+// attachRemaining attaches the comments left at the end of the input to
+// the ruleset
+func (p *Parser) attachRemaining() {
+	for _, c := range p.comments {
+		p.rulesetComment(c)
+	}
+	p.comments = nil
+}
+
+// rulesetComment attaches c to the ruleset: as a leading comment until a
+// rule is found, as a trailing one afterwards.
+func (p *Parser) rulesetComment(c *comment) {
+	if p.ruleSeen {
+		p.Comments = addTrailing(p.Comments, c.text)
+	} else {
+		p.Comments = addLeading(p.Comments, c.text)
+	}
+}
+
+func addLeading(c *Comments, text string) *Comments {
+	if c == nil {
+		c = &Comments{}
+	}
+	c.Leading = append(c.Leading, text)
+	return c
+}
+
+func addTrailing(c *Comments, text string) *Comments {
+	if c == nil {
+		c = &Comments{}
+	}
+	c.Trailing = append(c.Trailing, text)
+	return c
+}
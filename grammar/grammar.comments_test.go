@@ -0,0 +1,38 @@
+package grammar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConditionComments(t *testing.T) {
+	src := "rule c {\r\n" +
+		"\tstrings:\r\n" +
+		"\t\t$a = \"a\" // the a\r\n" +
+		"\tcondition: // why\r\n" +
+		"\t\t// first part\r\n" +
+		"\t\t$a and\r\n" +
+		"\t\t// second part\r\n" +
+		"\t\tfilesize < 10 // small\r\n" +
+		"\t\t// after\r\n" +
+		"}\r\n"
+	p := New("test.yar")
+	if err := p.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	rule := p.Rules[0]
+	if got, want := rule.Strings[0].Comments.Trailing, []string{"// the a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("string comments = %q, want %q", got, want)
+	}
+	want := &Comments{
+		Leading:  []string{"// why", "// first part", "// second part"},
+		Trailing: []string{"// small"},
+		Inner:    []string{"// after"},
+	}
+	if rule.Comments == nil || !reflect.DeepEqual(rule.Comments.Condition, want) {
+		t.Errorf("condition comments = %+v, want %+v", rule.Comments, want)
+	}
+	if len(rule.Comments.Inner) != 0 {
+		t.Errorf("rule inner comments = %q, want none", rule.Comments.Inner)
+	}
+}
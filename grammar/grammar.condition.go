@@ -0,0 +1,417 @@
+package grammar
+
+import (
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// Operator precedence in conditions, from the loosest to the tightest
+// binding, following the Yara grammar.
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precNot
+	precEqual
+	precRelational
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precAdditive
+	precMultiplicative
+)
+
+type binaryOp struct {
+	op   string
+	prec int
+}
+
+// binaryOps maps kinds of items to the binary operators they stand for
+var binaryOps = map[lexic.Kind]binaryOp{
+	lexic.ItemKWOr:          {"or", precOr},
+	lexic.ItemKWAnd:         {"and", precAnd},
+	lexic.ItemEqualEqual:    {"==", precEqual},
+	lexic.ItemNotEqual:      {"!=", precEqual},
+	lexic.ItemKWContains:    {"contains", precEqual},
+	lexic.ItemKWIcontains:   {"icontains", precEqual},
+	lexic.ItemKWStartswith:  {"startswith", precEqual},
+	lexic.ItemKWIstartswith: {"istartswith", precEqual},
+	lexic.ItemKWEndswith:    {"endswith", precEqual},
+	lexic.ItemKWIendswith:   {"iendswith", precEqual},
+	lexic.ItemKWIequals:     {"iequals", precEqual},
+	lexic.ItemKWMatches:     {"matches", precEqual},
+	lexic.ItemLess:          {"<", precRelational},
+	lexic.ItemLessEqual:     {"<=", precRelational},
+	lexic.ItemGrater:        {">", precRelational},
+	lexic.ItemGraterEqual:   {">=", precRelational},
+	lexic.ItemPipe:          {"|", precBitOr},
+	lexic.ItemCaret:         {"^", precBitXor},
+	lexic.ItemAnd:           {"&", precBitAnd},
+	lexic.ItemLeftShift:     {"<<", precShift},
+	lexic.ItemRightShift:    {">>", precShift},
+	lexic.ItemPlus:          {"+", precAdditive},
+	lexic.ItemDash:          {"-", precAdditive},
+	lexic.ItemStar:          {"*", precMultiplicative},
+	lexic.ItemBackSlash:     {"\\", precMultiplicative},
+	lexic.ItemPercent:       {"%", precMultiplicative},
+}
+
+// condParser builds the expression tree of a condition from its items
+type condParser struct {
+	p     *Parser
+	items []lexic.Item
+	pos   int
+	end   lexic.Item // item closing the condition
+}
+
+func newCondParser(p *Parser, items []lexic.Item) *condParser {
+	return &condParser{
+		p:     p,
+		items: items,
+		end:   p.LastItem,
+	}
+}
+
+// parse returns the expression tree of the whole condition
+func (c *condParser) parse() *Expr {
+	x := c.expr(precOr)
+	if c.pos < len(c.items) {
+		c.p.expected(c.peek(), lexic.ItemCCurly)
+	}
+	return x
+}
+
+// peekN returns but does not consume the item n positions ahead
+func (c *condParser) peekN(n int) lexic.Item {
+	if c.pos+n < len(c.items) {
+		return c.items[c.pos+n]
+	}
+	return c.end
+}
+
+func (c *condParser) peek() lexic.Item {
+	return c.peekN(0)
+}
+
+func (c *condParser) next() lexic.Item {
+	item := c.peek()
+	if c.pos < len(c.items) {
+		c.pos++
+	}
+	return item
+}
+
+func (c *condParser) is(kind lexic.Kind) bool {
+	return c.peek().Kind == kind
+}
+
+// expect consumes the next item, which must be of the given kind
+func (c *condParser) expect(kind lexic.Kind) lexic.Item {
+	item := c.next()
+	if item.Kind != kind {
+		c.p.expected(item, kind)
+	}
+	return item
+}
+
+// at records that x was read from item
+func (c *condParser) at(x *Expr, item lexic.Item) *Expr {
+	x.Pos = c.p.position(item)
+	return x
+}
+
+// adjacent reports whether the next item follows item without blanks
+func (c *condParser) adjacent(item lexic.Item) bool {
+	return c.peek().GetPos() == item.GetPos()+len(item.GetValue())
+}
+
+// expr parses a binary expression whose operators bind at least as tight
+// as prec.
+func (c *condParser) expr(prec int) *Expr {
+	var x *Expr
+	if prec <= precNot && (c.is(lexic.ItemKWNot) || c.is(lexic.ItemKWDefined)) {
+		x = newOpExpr(ExprUnary, c.next().GetValue(), c.expr(precNot))
+	} else {
+		x = c.unary()
+	}
+	for {
+		item := c.peek()
+		if item.Kind == lexic.ItemKWOf && prec <= precEqual { // 2 of them
+			c.next()
+			x = c.of(x)
+			continue
+		}
+		if item.Kind == lexic.ItemPercent && c.peekN(1).Kind == lexic.ItemKWOf { // 50% of them
+			if prec > precEqual {
+				return x
+			}
+			c.next()
+			c.next()
+			x = c.of(newExpr(ExprPercent, "", x))
+			continue
+		}
+		op, ok := binaryOps[item.Kind]
+		if !ok || op.prec < prec {
+			return x
+		}
+		c.next()
+		x = newOpExpr(ExprBinary, op.op, x, c.expr(op.prec+1))
+	}
+}
+
+func (c *condParser) unary() *Expr {
+	switch {
+	case c.is(lexic.ItemDash):
+		c.next()
+		return newOpExpr(ExprUnary, "-", c.unary())
+	case c.is(lexic.ItemBitNot):
+		c.next()
+		return newOpExpr(ExprUnary, "~", c.unary())
+	}
+	return c.postfix(c.primary())
+}
+
+func (c *condParser) primary() *Expr {
+	item := c.next()
+	switch {
+	case item.Kind == lexic.ItemKWTrue || item.Kind == lexic.ItemKWFalse:
+		return newExpr(ExprBool, item.GetValue())
+	case item.Kind == lexic.ItemIntNumber: // 10, 0x5a4d, 200KB
+		x := newExpr(ExprInt, item.GetValue())
+		x.Int = &item.Int
+		return x
+	case item.Kind == lexic.ItemFloatNumber:
+		x := newExpr(ExprFloat, item.GetValue())
+		x.Float = &item.Float
+		return x
+	case item.Kind == lexic.ItemIdentifier:
+		return c.at(newExpr(ExprIdentifier, item.GetValue()), item)
+	case item.Kind == lexic.ItemString:
+		return newExpr(ExprText, item.GetValue())
+	case item.Kind == lexic.ItemRegex:
+		return newExpr(ExprRegex, item.GetValue())
+	case item.Kind == lexic.ItemKWFilesize ||
+		item.Kind == lexic.ItemKWEntrypoint ||
+		item.Kind == lexic.ItemKWThem ||
+		item.Kind == lexic.ItemKWAll ||
+		item.Kind == lexic.ItemKWAny ||
+		item.Kind == lexic.ItemKWNone:
+		return c.at(newExpr(ExprKeyword, item.GetValue()), item)
+	case isIntFunction(item): // uint16(0)
+		return c.at(newExpr(ExprIdentifier, item.GetValue()), item)
+	case item.Kind == lexic.ItemVariable:
+		return c.stringRef(item)
+	case item.Kind == lexic.ItemHash:
+		x := c.at(newExpr(ExprCount, "#"+c.stringName(item)), item)
+		if c.is(lexic.ItemKWIn) {
+			c.next()
+			x.Args = append(x.Args, c.rangeExpr())
+		}
+		return x
+	case item.Kind == lexic.ItemAt:
+		return c.stringIndex(c.at(newExpr(ExprOffset, "@"+c.stringName(item)), item))
+	case item.Kind == lexic.ItemNot:
+		return c.stringIndex(c.at(newExpr(ExprLength, "!"+c.stringName(item)), item))
+	case item.Kind == lexic.ItemOBracket:
+		return c.paren()
+	case item.Kind == lexic.ItemKWFor:
+		return c.at(c.forExpr(), item)
+	}
+	c.p.errorAt(item, "Expected expression found %s", item.Kind)
+	return nil
+}
+
+// postfix parses member access, indexing and function calls
+func (c *condParser) postfix(x *Expr) *Expr {
+	for {
+		switch {
+		case c.is(lexic.ItemDot):
+			c.next()
+			x = newExpr(ExprMember, c.expect(lexic.ItemIdentifier).GetValue(), x)
+		case c.is(lexic.ItemOSqrt):
+			c.next()
+			x = newExpr(ExprIndex, "", x, c.expr(precOr))
+			c.expect(lexic.ItemCSqrt)
+		case c.is(lexic.ItemOBracket) && (x.Kind == ExprIdentifier || x.Kind == ExprMember):
+			c.next()
+			x = newExpr(ExprCall, "", x)
+			for !c.is(lexic.ItemCBracket) {
+				x.Args = append(x.Args, c.expr(precOr))
+				if !c.is(lexic.ItemComma) {
+					break
+				}
+				c.next()
+			}
+			c.expect(lexic.ItemCBracket)
+		default:
+			return x
+		}
+	}
+}
+
+// stringRef parses $a, $a at expr and $a in (x..y)
+func (c *condParser) stringRef(item lexic.Item) *Expr {
+	x := c.at(newExpr(ExprString, item.GetValue()), item)
+	switch {
+	case c.is(lexic.ItemKWAt):
+		c.next()
+		return newOpExpr(ExprBinary, "at", x, c.expr(precBitOr))
+	case c.is(lexic.ItemKWIn):
+		c.next()
+		return newOpExpr(ExprBinary, "in", x, c.rangeExpr())
+	}
+	return x
+}
+
+// stringName returns the string name following #, @ or !, which is empty
+// for anonymous references inside for..of loops.
+func (c *condParser) stringName(item lexic.Item) string {
+	if c.adjacent(item) && (c.is(lexic.ItemIdentifier) || c.is(lexic.ItemIntNumber)) {
+		return c.next().GetValue()
+	}
+	return ""
+}
+
+// stringIndex parses the optional [i] following @a and !a
+func (c *condParser) stringIndex(x *Expr) *Expr {
+	if c.is(lexic.ItemOSqrt) {
+		c.next()
+		x.Args = append(x.Args, c.expr(precOr))
+		c.expect(lexic.ItemCSqrt)
+	}
+	return x
+}
+
+// paren parses a parenthesised expression, a range or an enumeration
+func (c *condParser) paren() *Expr {
+	x := c.expr(precOr)
+	switch {
+	case c.is(lexic.ItemDotDot):
+		c.next()
+		x = newExpr(ExprRange, "", x, c.expr(precOr))
+	case c.is(lexic.ItemComma):
+		x = newExpr(ExprSet, "", x)
+		for c.is(lexic.ItemComma) {
+			c.next()
+			x.Args = append(x.Args, c.expr(precOr))
+		}
+	default:
+		x = newExpr(ExprParen, "", x)
+	}
+	c.expect(lexic.ItemCBracket)
+	return x
+}
+
+// rangeExpr parses (x..y)
+func (c *condParser) rangeExpr() *Expr {
+	c.expect(lexic.ItemOBracket)
+	lo := c.expr(precBitOr)
+	c.expect(lexic.ItemDotDot)
+	hi := c.expr(precBitOr)
+	c.expect(lexic.ItemCBracket)
+	return newExpr(ExprRange, "", lo, hi)
+}
+
+// of parses the set following quantifier of and, for sets of strings, the
+// at or in anchor of their matches
+func (c *condParser) of(quantifier *Expr) *Expr {
+	x := newExpr(ExprOf, "", quantifier, c.set())
+	if isRuleSet(x.Args[1]) {
+		return x
+	}
+	switch {
+	case c.is(lexic.ItemKWAt):
+		x.Op = c.next().GetValue()
+		x.Args = append(x.Args, c.expr(precBitOr))
+	case c.is(lexic.ItemKWIn):
+		x.Op = c.next().GetValue()
+		x.Args = append(x.Args, c.rangeExpr())
+	}
+	return x
+}
+
+// set parses them or a list of strings, $a*-like wildcards or rules, which
+// cannot be mixed
+func (c *condParser) set() *Expr {
+	item := c.next()
+	if item.Kind == lexic.ItemKWThem {
+		return c.at(newExpr(ExprKeyword, item.GetValue()), item)
+	}
+	if item.Kind != lexic.ItemOBracket {
+		c.p.expected(item, lexic.ItemKWThem, lexic.ItemOBracket)
+	}
+	x := newExpr(ExprSet, "")
+	for {
+		item = c.next()
+		kind := ExprString
+		if item.Kind == lexic.ItemIdentifier {
+			kind = ExprIdentifier
+		} else if item.Kind != lexic.ItemVariable {
+			c.p.expected(item, lexic.ItemVariable, lexic.ItemIdentifier)
+		}
+		if len(x.Args) > 0 && x.Args[0].Kind != kind {
+			c.p.errorAt(item, "Strings and rules cannot be mixed in a set")
+		}
+		name := item.GetValue()
+		if c.is(lexic.ItemStar) && c.adjacent(item) {
+			name += c.next().GetValue()
+		}
+		x.Args = append(x.Args, c.at(newExpr(kind, name), item))
+		if !c.is(lexic.ItemComma) {
+			break
+		}
+		c.next()
+	}
+	c.expect(lexic.ItemCBracket)
+	return x
+}
+
+// isRuleSet reports whether set is a set of rules rather than strings
+func isRuleSet(set *Expr) bool {
+	return set.Kind == ExprSet && len(set.Args) > 0 && set.Args[0].Kind == ExprIdentifier
+}
+
+// quantifier parses all, any, none, an expression or a percentage
+func (c *condParser) quantifier() *Expr {
+	if c.is(lexic.ItemKWAll) || c.is(lexic.ItemKWAny) || c.is(lexic.ItemKWNone) {
+		return newExpr(ExprKeyword, c.next().GetValue())
+	}
+	x := c.expr(precBitOr)
+	if c.is(lexic.ItemPercent) {
+		c.next()
+		x = newExpr(ExprPercent, "", x)
+	}
+	return x
+}
+
+// forExpr parses for..of and for..in loops
+func (c *condParser) forExpr() *Expr {
+	quantifier := c.quantifier()
+	if c.is(lexic.ItemKWOf) {
+		c.next()
+		set := c.set()
+		return newExpr(ExprForOf, "", quantifier, set, c.forBody())
+	}
+	var vars []string
+	for {
+		vars = append(vars, c.expect(lexic.ItemIdentifier).GetValue())
+		if !c.is(lexic.ItemComma) {
+			break
+		}
+		c.next()
+	}
+	c.expect(lexic.ItemKWIn)
+	iterable := c.unary()
+	x := newExpr(ExprForIn, "", quantifier, iterable, c.forBody())
+	x.Vars = vars
+	return x
+}
+
+// forBody parses : (expr)
+func (c *condParser) forBody() *Expr {
+	c.expect(lexic.ItemColon)
+	c.expect(lexic.ItemOBracket)
+	x := c.expr(precOr)
+	c.expect(lexic.ItemCBracket)
+	return x
+}
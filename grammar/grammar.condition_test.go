@@ -0,0 +1,152 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+// parseCondition parses a rule with the given condition and returns it
+func parseCondition(t *testing.T, condition string) RuleDef {
+	t.Helper()
+	p := New("test.yar")
+	if err := p.Parse("rule test { condition: " + condition + " }"); err != nil {
+		t.Fatalf("Parse(%q): %v", condition, err)
+	}
+	if len(p.Rules) != 1 {
+		t.Fatalf("Parse(%q): %d rules, want 1", condition, len(p.Rules))
+	}
+	return p.Rules[0]
+}
+
+func TestIntegerDivision(t *testing.T) {
+	rule := parseCondition(t, `filesize \ 2 > 100`)
+	if want := `filesize \ 2 > 100`; rule.Condition != want {
+		t.Errorf("Condition = %q, want %q", rule.Condition, want)
+	}
+	cmp := rule.ConditionAST
+	if cmp.Kind != ExprBinary || cmp.Op != ">" {
+		t.Fatalf("root = %s %q, want binary >", cmp.Kind, cmp.Op)
+	}
+	if div := cmp.Args[0]; div.Kind != ExprBinary || div.Op != `\` {
+		t.Errorf("left operand = %s %q, want binary \\", div.Kind, div.Op)
+	}
+}
+
+func TestIntegerDivisionPrecedence(t *testing.T) {
+	rule := parseCondition(t, `(filesize + 4 \ 2) * 3 == 6`)
+	if want := `(filesize + 4 \ 2) * 3 == 6`; rule.Condition != want {
+		t.Errorf("Condition = %q, want %q", rule.Condition, want)
+	}
+	sum := rule.ConditionAST.Args[0].Args[0].Args[0]
+	if sum.Op != "+" || sum.Args[1].Op != `\` {
+		t.Errorf("%s: \\ does not bind tighter than +", rule.Condition)
+	}
+}
+
+// tree renders x as an s-expression: leaves are their value, or their kind
+// when they have none, and other nodes list their operator or kind, value,
+// loop variables and operands.
+func tree(x *Expr) string {
+	if len(x.Args) == 0 && len(x.Vars) == 0 {
+		if x.Value == "" {
+			return x.Kind
+		}
+		return x.Value
+	}
+	parts := []string{x.Kind}
+	switch {
+	case x.Kind == ExprBinary || x.Kind == ExprUnary:
+		parts[0] = x.Op
+	case x.Op != "":
+		parts = append(parts, x.Op)
+	}
+	if x.Value != "" {
+		parts = append(parts, x.Value)
+	}
+	if len(x.Vars) > 0 {
+		parts = append(parts, "["+strings.Join(x.Vars, " ")+"]")
+	}
+	for _, arg := range x.Args {
+		parts = append(parts, tree(arg))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestConditionTree(t *testing.T) {
+	tests := []struct{ condition, want string }{
+		// and binds tighter than or, both associate to the left
+		{"a or b and c", "(or a (and b c))"},
+		{"a and b or c", "(or (and a b) c)"},
+		{"a or b or c", "(or (or a b) c)"},
+		{"a and b and c", "(and (and a b) c)"},
+		// not binds tighter than and, looser than comparisons
+		{"not a and b", "(and (not a) b)"},
+		{"not a or not b", "(or (not a) (not b))"},
+		{"not a == b", "(not (== a b))"},
+		{"not defined a", "(not (defined a))"},
+		// comparisons
+		{"a == b and c != d", "(and (== a b) (!= c d))"},
+		{"1 < 2 == true", "(== (< 1 2) true)"},
+		{"filesize >= 10 and filesize <= 20", "(and (>= filesize 10) (<= filesize 20))"},
+		{`a matches /x/i`, "(matches a /x/i)"},
+		{`"ab" contains "b"`, "(contains ab b)"},
+		// bitwise, shift and arithmetic operators
+		{"1 | 2 ^ 3 & 4", "(| 1 (^ 2 (& 3 4)))"},
+		{"1 & 2 | 3", "(| (& 1 2) 3)"},
+		{"1 << 2 + 3", "(<< 1 (+ 2 3))"},
+		{"1 | 2 < 3", "(< (| 1 2) 3)"},
+		{"1 + 2 * 3", "(+ 1 (* 2 3))"},
+		{"1 - 2 - 3", "(- (- 1 2) 3)"},
+		{`1 \ 2 % 3`, `(% (\ 1 2) 3)`},
+		{"-1 + ~2", "(+ (- 1) (~ 2))"},
+		{"(a or b) and c", "(and (paren (or a b)) c)"},
+		// strings
+		{"$a at 100 and $b in (0..filesize)", "(and (at $a 100) (in $b (range 0 filesize)))"},
+		{"#a in (0..100) > 2", "(> (count #a (range 0 100)) 2)"},
+		{"@a[1] + !a[2] < 10", "(< (+ (offset @a 1) (length !a 2)) 10)"},
+		// of
+		{"2 of ($a, $b*)", "(of 2 (set $a $b*))"},
+		{"50% of them", "(of (percent 50) them)"},
+		{"all of ($a*) at 0", "(of at all (set $a*) 0)"},
+		{"any of them in (0..100)", "(of in any them (range 0 100))"},
+		{"any of (r1, r2) and c", "(and (of any (set r1 r2)) c)"},
+		// loops
+		{"for any i in (1..#a) : (@a[i] > 0)", "(for_in [i] any (range 1 #a) (> (offset @a i) 0))"},
+		{"for all k, v in pe.version_info : (k == \"x\")", "(for_in [k v] all (member version_info pe) (== k x))"},
+		{"for 2 of ($a, $b) : ($ at 0)", "(for_of 2 (set $a $b) (at $ 0))"},
+		// module members, indexing and function calls
+		{"pe.sections[0].name == \".text\"", "(== (member name (index (member sections pe) 0)) .text)"},
+		{"uint16(0) == 0x5A4D", "(== (call uint16 0) 0x5A4D)"},
+		{"math.entropy(0, filesize) >= 7.0", "(>= (call (member entropy math) 0 filesize) 7.0)"},
+	}
+	for _, test := range tests {
+		rule := parseCondition(t, test.condition)
+		if got := tree(rule.ConditionAST); got != test.want {
+			t.Errorf("%s: tree %s, want %s", test.condition, got, test.want)
+		}
+	}
+}
+
+func TestConditionErrors(t *testing.T) {
+	tests := []struct{ condition, want string }{
+		{"a and", "3:1: Expected expression found __CLOSE_CURLY__"},
+		{"(a or b", "3:1: Expected __CLOSE_BRACKET__ found __CLOSE_CURLY__"},
+		{"a b", "2:15: Expected __CLOSE_CURLY__ found __IDENTIFIER__"},
+		{"pe.", "3:1: Expected __IDENTIFIER__ found __CLOSE_CURLY__"},
+		{"2 of ($a, r)", "2:23: Strings and rules cannot be mixed in a set"},
+		{"2 of $a", "2:18: Expected __KW_THEM__ or __OPEN_BRACKET__ found __VARIABLE__"},
+		{"for any i in (1..2) (i)", "2:33: Expected __COLON__ found __OPEN_BRACKET__"},
+		{"#a in 5", "2:19: Expected __OPEN_BRACKET__ found __INT_NUMBER__"},
+	}
+	for _, test := range tests {
+		err := New("test.yar").Parse("rule test {\n condition: " + test.condition + "\n}")
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%s: error %v, want a *ParseError", test.condition, err)
+			continue
+		}
+		if want := "test.yar:" + test.want; pe.Kind != SyntacticalError || pe.Error() != want {
+			t.Errorf("%s: %s error %v, want syntactical error %s", test.condition, pe.Kind, pe, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of errors reported by the parser
+const (
+	LexicalError     = "lexical"
+	SyntacticalError = "syntactical"
+	IncludeError     = "include"
+	SemanticError    = "semantic"
+)
+
+// Severity of the warnings, which do not stop Yara from compiling rules.
+// Errors have no severity.
+const SeverityWarning = "warning"
+
+// Codes of the warnings and semantic errors reported by the parser and
+// Validate
+const (
+	DuplicateImport = "duplicate_import"
+
+	UndefinedString = "undefined_string"
+	UnusedString    = "unused_string"
+	UndefinedRule   = "undefined_rule"
+	UndefinedModule = "undefined_module"
+	RuleOrder       = "rule_order"
+	LoopVariable    = "loop_variable"
+	LoopNesting     = "loop_nesting"
+)
+
+// Kinds lists the kinds of errors reported by the parser
+var Kinds = []string{LexicalError, SyntacticalError, IncludeError, SemanticError}
+
+// Codes lists the codes of the warnings and semantic errors
+var Codes = []string{
+	DuplicateImport,
+	UndefinedString,
+	UnusedString,
+	UndefinedRule,
+	UndefinedModule,
+	RuleOrder,
+	LoopVariable,
+	LoopNesting,
+}
+
+// ParseError describes why and where a rule file could not be parsed
+type ParseError struct {
+	Kind       string   `json:"kind"`
+	Code       string   `json:"code,omitempty"`
+	Severity   string   `json:"severity,omitempty"` // SeverityWarning for warnings
+	FileName   string   `json:"file_name"`
+	Rule       string   `json:"rule,omitempty"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	RuneColumn int      `json:"rune_column,omitempty"`
+	Offset     int      `json:"offset"`
+	Expected   []string `json:"expected,omitempty"`
+	Found      string   `json:"found,omitempty"`
+	Msg        string   `json:"msg"`
+}
+
+// Error returns the error formatted as file:line:column: message
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.FileName, e.Line, e.Column, e.Msg)
+}
+
+// IsWarning reports whether e is a warning rather than an error
+func (e *ParseError) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// expectedMsg builds the usual "Expected X or Y found Z" message
+func expectedMsg(expected []string, found string) string {
+	return fmt.Sprintf("Expected %s found %s", strings.Join(expected, " or "), found)
+}
@@ -0,0 +1,171 @@
+package grammar
+
+import (
+	"strings"
+)
+
+// Kinds of condition expression nodes
+const (
+	ExprBool       = "bool"       // true or false, Value holds the literal
+	ExprInt        = "int"        // integer number, Value holds the literal and Int its value
+	ExprFloat      = "float"      // float number, Value holds the literal and Float its value
+	ExprText       = "text"       // quoted string, Value holds the escaped text
+	ExprRegex      = "regex"      // regular expression, Value holds /body/flags
+	ExprKeyword    = "keyword"    // filesize, entrypoint, them, all, any and none
+	ExprIdentifier = "identifier" // rule reference, module or loop variable
+	ExprString     = "string"     // string reference: $a, $ or a $a* wildcard in a set
+	ExprCount      = "count"      // #a, Args[0] is the optional range of #a in (x..y)
+	ExprOffset     = "offset"     // @a, Args[0] is the optional index of @a[i]
+	ExprLength     = "length"     // !a, Args[0] is the optional index of !a[i]
+	ExprMember     = "member"     // Args[0].Value
+	ExprIndex      = "index"      // Args[0][Args[1]]
+	ExprCall       = "call"       // Args[0](Args[1:]...)
+	ExprParen      = "paren"      // (Args[0])
+	ExprUnary      = "unary"      // Op Args[0], Op being -, ~, not or defined
+	ExprBinary     = "binary"     // Args[0] Op Args[1], including at, in, matches and contains
+	ExprRange      = "range"      // (Args[0]..Args[1])
+	ExprSet        = "set"        // (Args[0], Args[1], ...)
+	ExprPercent    = "percent"    // Args[0]%
+	ExprOf         = "of"         // Args[0] of Args[1], anchored with Op at or in Args[2] for strings
+	ExprForOf      = "for_of"     // for Args[0] of Args[1] : (Args[2])
+	ExprForIn      = "for_in"     // for Args[0] Vars in Args[1] : (Args[2])
+)
+
+// Precedence of the nodes binding tighter than any binary operator
+const (
+	PrecedenceUnary = precMultiplicative + 1 + iota
+	PrecedenceAtom
+)
+
+// Expr is a node of the expression tree of a rule condition
+type Expr struct {
+	Kind  string    `json:"kind"`
+	Op    string    `json:"op,omitempty"`
+	Value string    `json:"value,omitempty"`
+	Int   *int64    `json:"int,omitempty"`   // value of int nodes, zero included
+	Float *float64  `json:"float,omitempty"` // value of float nodes, zero included
+	Vars  []string  `json:"vars,omitempty"`
+	Args  []*Expr   `json:"args,omitempty"`
+	Pos   *Position `json:"pos,omitempty"` // where identifiers and string references were read from
+}
+
+// Walk traverses the expression tree in depth-first order. Children of a
+// node are skipped when fn returns false.
+func (e *Expr) Walk(fn func(*Expr) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	for _, a := range e.Args {
+		a.Walk(fn)
+	}
+}
+
+// Precedence returns how tight the node binds, from 1 for or up to
+// PrecedenceAtom for nodes that never need parentheses.
+func (e *Expr) Precedence() int {
+	switch e.Kind {
+	case ExprBinary:
+		switch e.Op {
+		case "at", "in":
+			return precEqual
+		}
+		for _, op := range binaryOps {
+			if op.op == e.Op {
+				return op.prec
+			}
+		}
+	case ExprUnary:
+		if e.Op == "not" || e.Op == "defined" {
+			return precNot
+		}
+		return PrecedenceUnary
+	case ExprOf:
+		return precEqual
+	}
+	return PrecedenceAtom
+}
+
+// String renders the expression on a single line with canonical spacing,
+// adding parentheses where the tree needs them.
+func (e *Expr) String() string {
+	switch e.Kind {
+	case ExprText:
+		return "\"" + e.Value + "\""
+	case ExprCount:
+		if len(e.Args) > 0 {
+			return e.Value + " in " + e.Args[0].String()
+		}
+		return e.Value
+	case ExprOffset, ExprLength:
+		if len(e.Args) > 0 {
+			return e.Value + "[" + e.Args[0].String() + "]"
+		}
+		return e.Value
+	case ExprMember:
+		return e.Args[0].operand(PrecedenceAtom) + "." + e.Value
+	case ExprIndex:
+		return e.Args[0].operand(PrecedenceAtom) + "[" + e.Args[1].String() + "]"
+	case ExprCall:
+		return e.Args[0].operand(PrecedenceAtom) + "(" + joinExprs(e.Args[1:]) + ")"
+	case ExprParen:
+		return "(" + e.Args[0].String() + ")"
+	case ExprUnary:
+		if e.Op == "not" || e.Op == "defined" {
+			return e.Op + " " + e.Args[0].operand(e.Precedence())
+		}
+		return e.Op + e.Args[0].operand(e.Precedence())
+	case ExprBinary:
+		prec := e.Precedence()
+		switch e.Op {
+		case "at":
+			return e.Args[0].operand(prec+1) + " at " + e.Args[1].operand(precBitOr)
+		case "in":
+			return e.Args[0].operand(prec+1) + " in " + e.Args[1].String()
+		}
+		return e.Args[0].operand(prec) + " " + e.Op + " " + e.Args[1].operand(prec+1)
+	case ExprRange:
+		return "(" + e.Args[0].String() + ".." + e.Args[1].String() + ")"
+	case ExprSet:
+		return "(" + joinExprs(e.Args) + ")"
+	case ExprPercent:
+		return e.Args[0].operand(PrecedenceUnary) + "%"
+	case ExprOf:
+		s := e.Args[0].operand(PrecedenceUnary) + " of " + e.Args[1].String()
+		switch e.Op {
+		case "at":
+			return s + " at " + e.Args[2].operand(precBitOr)
+		case "in":
+			return s + " in " + e.Args[2].String()
+		}
+		return s
+	case ExprForOf:
+		return "for " + e.Args[0].operand(PrecedenceUnary) + " of " + e.Args[1].String() + " : (" + e.Args[2].String() + ")"
+	case ExprForIn:
+		return "for " + e.Args[0].operand(PrecedenceUnary) + " " + strings.Join(e.Vars, ", ") + " in " + e.Args[1].String() + " : (" + e.Args[2].String() + ")"
+	}
+	return e.Value
+}
+
+// operand renders e wrapped in parentheses when it binds looser than prec
+func (e *Expr) operand(prec int) string {
+	if e.Precedence() < prec {
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+func joinExprs(args []*Expr) string {
+	items := make([]string, len(args))
+	for i, a := range args {
+		items[i] = a.String()
+	}
+	return strings.Join(items, ", ")
+}
+
+func newExpr(kind, value string, args ...*Expr) *Expr {
+	return &Expr{Kind: kind, Value: value, Args: args}
+}
+
+func newOpExpr(kind, op string, args ...*Expr) *Expr {
+	return &Expr{Kind: kind, Op: op, Args: args}
+}
@@ -0,0 +1,26 @@
+package grammar
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExprZeroNumbers(t *testing.T) {
+	rule := parseCondition(t, "filesize > 0 and math.entropy(0, filesize) > 0.0")
+	b, err := json.Marshal(rule.ConditionAST)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, want := range []string{
+		`{"kind":"int","value":"0","int":0}`,
+		`{"kind":"float","value":"0.0","float":0}`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("%s: missing %s", b, want)
+		}
+	}
+	if n := strings.Count(string(b), `"int":`); n != 2 {
+		t.Errorf("%s: %d nodes with an int, want 2", b, n)
+	}
+}
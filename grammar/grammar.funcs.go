@@ -6,66 +6,6 @@ import (
 	"github.com/Yara-Rules/yago/lexic"
 )
 
-// String returns a string representation of the Parser
-func (p *Parser) String() string {
-	r := ""
-	for _, imp := range p.Imports {
-		r += fmt.Sprintf("import \"%s\"\n", imp)
-	}
-
-	if len(p.Imports) > 0 {
-		r += fmt.Sprintf("\n")
-	}
-
-	for _, rule := range p.Rules {
-		if rule.Private {
-			r += fmt.Sprintf("private ")
-		}
-		if rule.Global {
-			r += fmt.Sprintf("global ")
-		}
-
-		r += fmt.Sprintf("rule %s", rule.Name)
-		if len(rule.Tags) > 0 {
-			r += " :"
-			for _, tag := range rule.Tags {
-				r += fmt.Sprintf(" %s", tag)
-			}
-			r += " {\n"
-		} else {
-			r += fmt.Sprintf(" {\n")
-		}
-		if len(rule.Meta) > 0 {
-			r += fmt.Sprintf("\tmeta:\n")
-			for k, v := range rule.Meta {
-				r += fmt.Sprintf("\t\t%s = \"%s\"\n", k, v)
-			}
-		}
-		if len(rule.Strings) > 0 {
-			r += fmt.Sprintf("\tstrings:\n")
-			for _, str := range rule.Strings {
-				if str.Typ == StringString {
-					r += fmt.Sprintf("\t\t%s = \"%s\"", str.Name, str.Value)
-					if len(str.Modifiers) > 0 {
-						for _, m := range str.Modifiers {
-							r += fmt.Sprintf(" %s", m)
-						}
-					}
-				} else if str.Typ == StringRegex {
-					r += fmt.Sprintf("\t\t%s = %s", str.Name, str.Value)
-				} else if str.Typ == StringHex {
-					r += fmt.Sprintf("\t\t%s = %s", str.Name, str.Value)
-				}
-				r += fmt.Sprintf("\n")
-			}
-		}
-		r += fmt.Sprintf("\tcondition:\n")
-		r += fmt.Sprintf("\t\t%s\n", rule.Condition)
-		r += fmt.Sprintf("}\n\n")
-	}
-	return r
-}
-
 func (p *Parser) addImport(item lexic.Item) bool {
 	if !p.moduleAlreadyImported(item) {
 		p.Imports = append(p.Imports, item.GetValue())
@@ -75,8 +15,12 @@ func (p *Parser) addImport(item lexic.Item) bool {
 }
 
 func (p *Parser) moduleAlreadyImported(item lexic.Item) bool {
+	return p.moduleImported(item.GetValue())
+}
+
+func (p *Parser) moduleImported(module string) bool {
 	for _, v := range p.Imports {
-		if v == item.GetValue() {
+		if v == module {
 			return true
 		}
 	}
@@ -84,14 +28,40 @@ func (p *Parser) moduleAlreadyImported(item lexic.Item) bool {
 }
 
 func (p *Parser) ruleAlreadyImported(ruleName string) bool {
-	for _, v := range p.Rules {
-		if v.Name == ruleName {
-			return true
-		}
-	}
-	return false
+	_, ok := p.ruleNames[ruleName]
+	return ok
 }
 
+// addRule adds rule to the rules parsed, or stops parsing when a rule of the
+// same name was added before
 func (p *Parser) addRule(rule RuleDef) {
+	if p.ruleAlreadyImported(rule.Name) {
+		panic(duplicateRule(rule))
+	}
+	if p.ruleNames == nil {
+		p.ruleNames = map[string]struct{}{}
+	}
+	p.ruleNames[rule.Name] = struct{}{}
+	if p.onRule != nil {
+		if err := p.onRule(rule); err != nil {
+			panic(&stopParsing{err})
+		}
+		return
+	}
 	p.Rules = append(p.Rules, rule)
 }
+
+// duplicateRule returns the error of rule being defined again, at the
+// beginning of its declaration
+func duplicateRule(rule RuleDef) *ParseError {
+	err := &ParseError{
+		Kind:     SyntacticalError,
+		FileName: rule.File,
+		Rule:     rule.Name,
+		Msg:      fmt.Sprintf("Rule %s alredy defined.", rule.Name),
+	}
+	if pos := positionOf(rule.Span); pos != nil {
+		err.Line, err.Column, err.RuneColumn, err.Offset = pos.Line, pos.Column, pos.RuneColumn, pos.Offset
+	}
+	return err
+}
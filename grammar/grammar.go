@@ -1,9 +1,7 @@
 package grammar
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/Yara-Rules/yago/lexic"
@@ -17,6 +15,7 @@ func New(name string) *Parser {
 		Name:      name,
 		peekCount: 0,
 		log:       logrus.New(),
+		ruleNames: map[string]struct{}{},
 	}
 }
 
@@ -33,42 +32,88 @@ func (p *Parser) SetLogLevel(level string) {
 	}
 }
 
-// Parse starts parsing the input
-func (p *Parser) Parse(text string) {
+// SetRecovery enables or disables the error recovery mode. When enabled a
+// broken statement is recorded in Diagnostics and parsing resumes at the
+// next rule, private, global, import or include keyword.
+func (p *Parser) SetRecovery(enabled bool) {
+	p.recovery = enabled
+}
+
+// Parse starts parsing the input. It returns a *ParseError when the input
+// is not a valid set of rules, unless the recovery mode is enabled.
+func (p *Parser) Parse(text string) (err error) {
 	p.log.Debugln(" ** Stating parser **")
+	defer p.recover(&err)
 	p.startParer(text)
 	p.parse()
 	p.log.Debugln(" ** Parser finished **")
+	return nil
 }
 
 func (p *Parser) startParer(text string) {
-	p.Lex = lexic.Lex(p.Name, text)
+	p.Lex = lexic.New(p.Name, text)
 }
 
-func (p *Parser) errorf(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	err := make(map[string]string)
+// recover turns the error raised while parsing into the one returned by
+// Parse.
+func (p *Parser) recover(errp *error) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	switch err := e.(type) {
+	case *ParseError:
+		*errp = err
+	case *stopParsing:
+		*errp = err
+	default:
+		panic(e)
+	}
+}
 
-	err["syntactical"] = "error"
-	err["line"] = fmt.Sprintf("%d", p.LastItem.GetLine())
-	err["file_name"] = p.Name
-	err["msg"] = msg
+// newError builds a ParseError located at item
+func (p *Parser) newError(kind string, item lexic.Item, msg string) *ParseError {
+	err := &ParseError{
+		Kind:     kind,
+		FileName: p.Name,
+		Rule:     p.rule,
+		Msg:      msg,
+	}
+	err.Line = item.Line
+	err.Offset, _ = item.Span()
+	err.Column = item.Column
+	err.RuneColumn = item.RuneColumn
+	return err
+}
 
-	j, _ := json.Marshal(err)
-	os.Stderr.Write(j)
-	os.Exit(1)
+// errorf stops parsing with a syntactical error at the last item read
+func (p *Parser) errorf(format string, args ...interface{}) {
+	p.errorAt(p.LastItem, format, args...)
 }
 
-func (p *Parser) warnf(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	err := make(map[string]string)
+// errorAt stops parsing with a syntactical error at item
+func (p *Parser) errorAt(item lexic.Item, format string, args ...interface{}) {
+	panic(p.newError(SyntacticalError, item, fmt.Sprintf(format, args...)))
+}
 
-	err["syntactical"] = "warning"
-	err["line"] = fmt.Sprintf("%d", p.LastItem.GetLine())
-	err["msg"] = msg
+// expected stops parsing because found is not any of the expected items
+func (p *Parser) expected(found lexic.Item, expected ...lexic.Kind) {
+	types := make([]string, len(expected))
+	for i, e := range expected {
+		types[i] = e.String()
+	}
+	err := p.newError(SyntacticalError, found, expectedMsg(types, found.Kind.String()))
+	err.Expected = types
+	err.Found = found.Kind.String()
+	panic(err)
+}
 
-	j, _ := json.Marshal(err)
-	os.Stderr.Write(j)
+// warnf records a warning with the given code at the last item read in
+// Diagnostics
+func (p *Parser) warnf(code, format string, args ...interface{}) {
+	warn := p.newError(SyntacticalError, p.LastItem, fmt.Sprintf(format, args...))
+	warn.Code, warn.Severity = code, SeverityWarning
+	p.Diagnostics = append(p.Diagnostics, warn)
 }
 
 func (p *Parser) getLastItem() lexic.Item {
@@ -83,6 +128,7 @@ func (p *Parser) backup() {
 // peek returns but does not consume the next token.
 func (p *Parser) peek() lexic.Item {
 	if p.peekCount > 0 {
+		p.raiseLexErr()
 		return p.token[p.peekCount-1]
 	}
 	p.peekCount = 1
@@ -90,10 +136,11 @@ func (p *Parser) peek() lexic.Item {
 	return p.token[0]
 }
 
+// nextNotComment reads the next item, comments are kept apart by fetch
 func (p *Parser) nextNotComment() lexic.Item {
-	item := p.Lex.NextItem()
-	for checkItemType(item, "__COMMENT__") { // Omit all comments
-		item = p.Lex.NextItem()
+	item, err := p.fetch()
+	if err != nil {
+		panic(err)
 	}
 	return item
 }
@@ -101,6 +148,7 @@ func (p *Parser) nextNotComment() lexic.Item {
 func (p *Parser) nextItem() lexic.Item {
 	if p.peekCount > 0 {
 		p.peekCount--
+		p.raiseLexErr()
 	} else {
 		p.token[0] = p.nextNotComment()
 	}
@@ -108,162 +156,207 @@ func (p *Parser) nextItem() lexic.Item {
 	return p.token[p.peekCount]
 }
 
+// recoverStatement records the error raised while parsing a statement and
+// leaves the input ready for the next one.
+func (p *Parser) recoverStatement(more *bool) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	pe, ok := e.(*ParseError)
+	if !ok {
+		panic(e)
+	}
+	p.log.Debugln("Recovering from: ", pe)
+	p.Diagnostics = append(p.Diagnostics, pe)
+	p.comments = nil // those of the broken statement
+	if p.peekCount == 0 && isSyncItem(p.LastItem) {
+		p.backup() // The offending item starts the next statement
+	}
+	*more = true
+}
+
 func (p *Parser) parse() {
+	for p.parseStatement() {
+	}
+	p.attachRemaining()
+}
+
+// parseStatement parses the next top level statement. Items that do not
+// start a statement are skipped, which is also how the parser resynchronises
+// after an error. It returns false at the end of the input.
+func (p *Parser) parseStatement() (more bool) {
+	if p.recovery {
+		defer p.recoverStatement(&more)
+	}
+	p.rule = ""
 	private := false
 	global := false
-	for item := range p.Lex.Items {
-		// p.log.Debugln("--> ", item)
-		switch {
-		case checkItemType(item, "__KW_IMPORT__"):
-			p.processImport()
-		case checkItemType(item, "__KW_PRIVATE__"):
-			private = true
-			item = p.Lex.NextItem()
-			if checkItemType(item, "__KW_GLOBAL__") {
-				global = true
-				item = p.Lex.NextItem()
-			}
-			if checkItemType(item, "__KW_RULE__") {
-				p.processRule(global, private)
-				private = false
-				global = false
-			}
-		case checkItemType(item, "__KW_GLOBAL__"):
+	item := p.nextItem()
+	start := item
+	// p.log.Debugln("--> ", item)
+	switch {
+	case item.Kind == lexic.ItemEOF:
+		return false
+	case item.Kind == lexic.ItemKWImport:
+		p.processImport()
+	case item.Kind == lexic.ItemKWInclude:
+		p.processInclude()
+	case item.Kind == lexic.ItemKWPrivate:
+		private = true
+		item = p.nextItem()
+		if item.Kind == lexic.ItemKWGlobal {
 			global = true
-			item = p.Lex.NextItem()
-			if checkItemType(item, "__KW_PRIVATE__") {
-				private = true
-				item = p.Lex.NextItem()
-			}
-			if checkItemType(item, "__KW_RULE__") {
-				p.processRule(global, private)
-				private = false
-				global = false
-			}
-		case checkItemType(item, "__KW_RULE__"):
-			p.processRule(global, private)
+			item = p.nextItem()
+		}
+		if item.Kind == lexic.ItemKWRule {
+			p.processRule(start, global, private)
+		}
+	case item.Kind == lexic.ItemKWGlobal:
+		global = true
+		item = p.nextItem()
+		if item.Kind == lexic.ItemKWPrivate {
+			private = true
+			item = p.nextItem()
+		}
+		if item.Kind == lexic.ItemKWRule {
+			p.processRule(start, global, private)
 		}
+	case item.Kind == lexic.ItemKWRule:
+		p.processRule(start, global, private)
 	}
+	return true
 }
 
 func (p *Parser) processImport() {
 	item := p.nextItem()
-	if checkItemType(item, "__STRING__") {
+	if item.Kind == lexic.ItemString {
 		if !p.addImport(item) {
-			p.warnf("Module %s already imported.\n", item.GetValue())
+			p.warnf(DuplicateImport, "Module %s already imported.", item.GetValue())
 		} else {
 			p.log.Debugln("Importing module: ", item)
 		}
 	} else {
-		p.errorf("Expected %s and found %s.\n", lexic.ItemType["ItemString"], item.GetType())
+		p.expected(item, lexic.ItemString)
 	}
 }
 
-func (p *Parser) processRule(global, private bool) {
+// processRule parses a rule, start is the item the rule declaration began with
+func (p *Parser) processRule(start lexic.Item, global, private bool) {
 	item := p.nextItem()
-	if checkItemType(item, "__IDENTIFIER__") {
-		if !p.ruleAlreadyImported(item.GetValue()) {
-			p.log.Debugln("Processing rule: ", item)
-			newRule := RuleDef{
-				Name:    item.GetValue(),
-				Global:  global,
-				Private: private,
-			}
+	if item.Kind == lexic.ItemIdentifier {
+		p.rule = item.GetValue()
+		p.log.Debugln("Processing rule: ", item)
+		newRule := RuleDef{
+			Name:    item.GetValue(),
+			Global:  global,
+			Private: private,
+		}
+		item = p.nextItem()
+		if item.Kind == lexic.ItemColon { // Tags comming
+			newRule.Tags = p.processTags()
+			p.log.Debugln("Tags list: ", newRule.Tags)
+		}
+		if p.LastItem.Kind == lexic.ItemOCurly {
 			item = p.nextItem()
-			if checkItemType(item, "__COLON__") { // Tags comming
-				newRule.Tags = p.processTags()
-				p.log.Debugln("Tags list: ", newRule.Tags)
-			}
-			if checkItemType(p.LastItem, "__OPEN_CURLY__") {
+			if item.Kind == lexic.ItemKWMeta { // Meta comming
 				item = p.nextItem()
-				if checkItemType(item, "__KW_META__") { // Meta comming
-					item = p.nextItem()
-					if checkItemType(item, "__COLON__") {
-						newRule.Meta = p.processMeta()
-						if len(newRule.Meta) == 0 {
-							p.errorf("%s found but not meta items defined", lexic.ItemType["ItemKWMeta"])
-						}
-					} else {
-						p.errorf("Expected %s found %s", lexic.ItemType["ItemColon"], item.GetType())
+				if item.Kind == lexic.ItemColon {
+					newRule.Meta = p.processMeta()
+					if len(newRule.Meta) == 0 {
+						p.errorf("%s found but not meta items defined", lexic.ItemKWMeta)
 					}
+				} else {
+					p.expected(item, lexic.ItemColon)
 				}
-				if checkItemType(p.LastItem, "__KW_STRINGS__") { // Strings comming
-					item = p.nextItem()
-					if checkItemType(item, "__COLON__") {
-						newRule.Strings = p.processStrings()
-						if len(newRule.Strings) == 0 {
-							p.errorf("%s found but not strings defined", lexic.ItemType["ItemKWStrings"])
-						}
-					} else {
-						p.errorf("Expected %s found %s", lexic.ItemType["ItemColon"], item.GetType())
+			}
+			if p.LastItem.Kind == lexic.ItemKWStrings { // Strings comming
+				item = p.nextItem()
+				if item.Kind == lexic.ItemColon {
+					newRule.Strings = p.processStrings()
+					if len(newRule.Strings) == 0 {
+						p.errorf("%s found but not strings defined", lexic.ItemKWStrings)
 					}
+				} else {
+					p.expected(item, lexic.ItemColon)
 				}
-				if checkItemType(p.LastItem, "__KW_CONDITION__") { // Condition comming
-					item = p.nextItem()
-					if checkItemType(item, "__COLON__") {
-						newRule.Condition = p.processCondition()
-						if len(newRule.Condition) == 0 {
-							p.errorf("%s found but not condition defined", lexic.ItemType["ItemKWCondition"])
-						} else {
-							p.log.Debugln("Condition: ", newRule.Condition)
-						}
-						p.addRule(newRule)
+			}
+			if p.LastItem.Kind == lexic.ItemKWCondition { // Condition comming
+				item = p.nextItem()
+				if item.Kind == lexic.ItemColon {
+					newRule.Condition, newRule.ConditionAST, newRule.ConditionSpan = p.processCondition()
+					if len(newRule.Condition) == 0 {
+						p.errorf("%s found but not condition defined", lexic.ItemKWCondition)
 					} else {
-						p.errorf("Expected %s found %s", lexic.ItemType["ItemColon"], item.GetType())
+						p.log.Debugln("Condition: ", newRule.Condition)
 					}
+					newRule.File = p.Name
+					newRule.Span = p.span(start, p.LastItem)
+					p.lookAhead()
+					p.attachComments(&newRule)
+					p.addRule(newRule)
 				} else {
-					p.errorf("Expected %s or %s or %s found %s", lexic.ItemType["ItemKWMeta"], lexic.ItemType["ItemKWStrings"], lexic.ItemType["ItemKWCondition"], item.GetType())
+					p.expected(item, lexic.ItemColon)
 				}
 			} else {
-				p.errorf("Expected %s found %s.", lexic.ItemType["ItemOCurly"], item.GetType())
+				p.expected(p.LastItem, lexic.ItemKWMeta, lexic.ItemKWStrings, lexic.ItemKWCondition)
 			}
 		} else {
-			p.errorf("Rule %s alredy defined.", item.GetValue())
+			p.expected(item, lexic.ItemOCurly)
 		}
 	} else {
-		p.errorf("Expected %s found %s.", lexic.ItemType["ItemIdentifier"], item.GetType())
+		p.expected(item, lexic.ItemIdentifier)
 	}
 }
 
 func (p *Parser) processTags() []string {
 	var tags []string
 	item := p.nextItem()
-	for !checkItemType(item, "__OPEN_CURLY__") {
-		if checkItemType(item, "__IDENTIFIER__") {
+	for item.Kind != lexic.ItemOCurly {
+		if item.Kind == lexic.ItemIdentifier {
 			tags = append(tags, item.GetValue())
 		} else {
-			p.errorf("Expecting %s or %s and found %s.", lexic.ItemType["ItemIdentifier"], lexic.ItemType["ItemOCurly"], item.GetType())
+			p.expected(item, lexic.ItemIdentifier, lexic.ItemOCurly)
 		}
 		item = p.nextItem()
 	}
 	return tags
 }
 
-func (p *Parser) processMeta() map[string]string {
+func (p *Parser) processMeta() MetaList {
 	var key, value lexic.Item
-	meta := make(map[string]string)
+	var meta MetaList
 	item := p.nextItem()
-	for !checkItemType(item, "__KW_STRINGS__") && !checkItemType(item, "__KW_CONDITION__") {
+	for item.Kind != lexic.ItemKWStrings && item.Kind != lexic.ItemKWCondition {
 		key = item
-		if checkItemType(item, "__IDENTIFIER__") {
+		if item.Kind == lexic.ItemIdentifier {
 			item = p.nextItem()
-			if checkItemType(item, "__EQUAL__") {
+			if item.Kind == lexic.ItemEqual {
 				item = p.nextItem()
 				value = item
-				if checkItemType(item, "__STRING__") ||
-					checkItemType(item, "__INT_NUMBER__") ||
-					checkItemType(item, "__KW_TRUE__") || // Yara allows boolans as values
-					checkItemType(item, "__KW_FLASE__") {
-					p.log.Debugln("Meta: ", key, " = ", item)
-					meta[key.GetValue()] = value.GetValue()
+				sign := ""
+				if item.Kind == lexic.ItemDash { // Negative integer
+					sign = item.GetValue()
+					item = p.nextItem()
+					value = item
+					if item.Kind != lexic.ItemIntNumber {
+						p.expected(item, lexic.ItemIntNumber)
+					}
+				}
+				if item.Kind == lexic.ItemString ||
+					item.Kind == lexic.ItemIntNumber ||
+					item.Kind == lexic.ItemKWTrue || // Yara allows boolans as values
+					item.Kind == lexic.ItemKWFalse {
+					p.log.Debugln("Meta: ", key, " = ", sign, item)
+					meta = append(meta, MetaDef{Key: key.GetValue(), Value: sign + value.GetValue(), Typ: metaType(value), Line: key.GetLine(), Span: p.span(key, value)})
 				} else {
-					p.errorf("Expected %s or %s found %s", lexic.ItemType["ItemString"], lexic.ItemType["ItemIntNumber"], item.GetType())
+					p.expected(item, lexic.ItemString, lexic.ItemIntNumber, lexic.ItemKWTrue, lexic.ItemKWFalse)
 				}
 			} else {
-				p.errorf("Expected %s found %s", lexic.ItemType["ItemEqual"], item.GetType())
+				p.expected(item, lexic.ItemEqual)
 			}
 		} else {
-			p.errorf("Expected %s found %s", lexic.ItemType["ItemIdentifier"], item.GetType())
+			p.expected(item, lexic.ItemIdentifier)
 		}
 		item = p.nextItem()
 	}
@@ -277,34 +370,47 @@ func (p *Parser) processStrings() []StringDef {
 	stringTable := []string{}
 
 	item := p.nextItem()
-	for !checkItemType(item, "__KW_CONDITION__") {
+	for item.Kind != lexic.ItemKWCondition {
 		key = item
 		if !stringDefined(stringTable, item) {
 			stringTable = append(stringTable, item.GetValue())
-			if checkItemType(item, "__VARIABLE__") {
+			if item.Kind == lexic.ItemVariable {
 				item = p.nextItem()
-				if checkItemType(item, "__EQUAL__") {
+				if item.Kind == lexic.ItemEqual {
 					item = p.nextItem()
-					if checkItemType(item, "__STRING__") ||
-						checkItemType(item, "__KW_TRUE__") || // Yara allows boolans as values
-						checkItemType(item, "__KW_FLASE__") {
-						value, mods := p.processStringModifiers()
-						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Modifiers: mods, Typ: StringString})
+					if item.Kind == lexic.ItemString ||
+						item.Kind == lexic.ItemKWTrue || // Yara allows boolans as values
+						item.Kind == lexic.ItemKWFalse {
+						value, decoded := item.GetValue(), item.Decoded
+						if item.Kind != lexic.ItemString {
+							decoded = value // true or false
+						}
+						mods := p.processStringModifiers(StringString)
+						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Bytes: []byte(decoded), Modifiers: mods, Typ: StringString, Span: p.span(key, p.LastItem)})
 						p.log.Debugln("String: ", key, " = ", value, " ", mods)
-					} else if checkItemType(item, "__REGEX__") {
-						value, mods := p.processStringModifiers()
-						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Modifiers: mods, Typ: StringRegex})
+					} else if item.Kind == lexic.ItemRegex {
+						value := item.GetValue()
+						body, flags := splitRegex(value)
+						mods := p.processStringModifiers(StringRegex)
+						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Modifiers: mods, Typ: StringRegex, Body: body, Flags: flags, Span: p.span(key, p.LastItem)})
 						p.log.Debugln("String: ", key, " = ", value, " ", mods)
-					} else if checkItemType(item, "__OPEN_CURLY__") {
+					} else if item.Kind == lexic.ItemOCurly {
 						value = p.processHexValues()
-						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Typ: StringHex})
-						p.log.Debugln("String: ", key, " = ", value)
+						hex, err := ParseHex(value)
+						if err != nil {
+							p.errorf("%s", err)
+						}
+						mods := p.processStringModifiers(StringHex)
+						strings = append(strings, StringDef{Name: key.GetValue(), Value: value, Modifiers: mods, Typ: StringHex, Hex: hex, Span: p.span(key, p.LastItem)})
+						p.log.Debugln("String: ", key, " = ", value, " ", mods)
+					} else {
+						p.expected(item, lexic.ItemString, lexic.ItemRegex, lexic.ItemOCurly)
 					}
 				} else {
-					p.errorf("Expected %s found %s", lexic.ItemType["ItemEqual"], item.GetType())
+					p.expected(item, lexic.ItemEqual)
 				}
 			} else {
-				p.errorf("Expected %s found %s", lexic.ItemType["ItemVariable"], item.GetType())
+				p.expected(item, lexic.ItemVariable)
 			}
 		} else {
 			p.errorf("Duplicated string identifier %s", item.GetValue())
@@ -314,39 +420,28 @@ func (p *Parser) processStrings() []StringDef {
 	return strings
 }
 
-func (p *Parser) processStringModifiers() (string, []string) {
-	value := p.LastItem
-	var mods []string
-	item := p.peek()
-	if isStringModifier(item) {
-		item = p.nextItem()
-		for isStringModifier(item) {
-			mods = append(mods, item.GetValue())
-			item = p.nextItem()
-		}
-		p.backup()
-	}
-	return value.GetValue(), mods
-}
-
 func (p *Parser) processHexValues() string {
 	value := "{"
 	item := p.nextItem()
-	for !checkItemType(item, "__CLOSE_CURLY__") {
+	for item.Kind != lexic.ItemCCurly {
 		switch {
-		case checkItemType(item, "__IDENTIFIER__"): // Hex value
+		case item.Kind == lexic.ItemIdentifier: // Hex value
 			value = value + item.GetValue()
 			break
-		case checkItemType(item, "__INT_NUMBER__"): // Number
+		case item.Kind == lexic.ItemIntNumber: // Number
 			value = value + item.GetValue()
 			break
-		case checkItemType(item, "__QMAKR__"): // ? Wildcard
+		case item.Kind == lexic.ItemQMark: // ? Wildcard
 			value = value + item.GetValue()
 			break
-		case checkItemType(item, "__OPEN_SQRT__"):
+		case item.Kind == lexic.ItemBitNot: // ~ Negation
+			value = value + item.GetValue()
+		case item.Kind == lexic.ItemOSqrt:
 			value = value + p.processHexRange()
-		case checkItemType(item, "__OPEN_BRACKET__"):
+		case item.Kind == lexic.ItemOBracket:
 			value = value + p.preocessHexOption()
+		default:
+			p.expected(item, lexic.ItemIdentifier, lexic.ItemIntNumber, lexic.ItemQMark, lexic.ItemBitNot, lexic.ItemOSqrt, lexic.ItemOBracket, lexic.ItemCCurly)
 		}
 		item = p.nextItem()
 	}
@@ -357,21 +452,21 @@ func (p *Parser) processHexValues() string {
 func (p *Parser) processHexRange() string {
 	value := "["
 	item := p.nextItem()
-	if checkItemType(item, "__INT_NUMBER__") {
+	if item.Kind == lexic.ItemIntNumber {
 		numA := item.GetValue()
 		nA, err := strconv.Atoi(numA)
 		if err == nil {
 			if nA < 0 {
-				p.errorf("Expected >= 0 integer found %s", lexic.ItemType["ItemCSqrt"], item.GetType())
+				p.errorf("Expected >= 0 integer found %s", item.GetValue())
 			}
 		} else {
-			p.errorf("Unable to convert %s", item.GetType())
+			p.errorf("Unable to convert %s", item.Kind)
 		}
 		item = p.nextItem()
-		if checkItemType(item, "__DASH__") {
+		if item.Kind == lexic.ItemDash {
 			dash := item.GetValue()
 			item = p.nextItem()
-			if checkItemType(item, "__INT_NUMBER__") {
+			if item.Kind == lexic.ItemIntNumber {
 				numB := item.GetValue()
 				nB, err := strconv.Atoi(numB)
 				if err == nil {
@@ -379,38 +474,38 @@ func (p *Parser) processHexRange() string {
 						p.errorf("Expected %s to be grather than %s", numB, numA)
 					}
 				} else {
-					p.errorf("Unable to convert %s", item.GetType())
+					p.errorf("Unable to convert %s", item.Kind)
 				}
 				item = p.nextItem()
-				if checkItemType(item, "__CLOSE_SQRT__") {
+				if item.Kind == lexic.ItemCSqrt {
 					value = value + numA + dash + numB + item.GetValue()
 					return value
 				} else {
-					p.errorf("Expected %s found %s", lexic.ItemType["ItemCSqrt"], item.GetType())
+					p.expected(item, lexic.ItemCSqrt)
 				}
-			} else if checkItemType(item, "__CLOSE_SQRT__") {
+			} else if item.Kind == lexic.ItemCSqrt {
 				value = value + numA + dash + item.GetValue()
 				return value
 			} else {
-				p.errorf("Expected %s or  found %s", lexic.ItemType["ItemIntNumber"], lexic.ItemType["ItemCSqrt"], item.GetType())
+				p.expected(item, lexic.ItemIntNumber, lexic.ItemCSqrt)
 			}
-		} else if checkItemType(item, "__CLOSE_SQRT__") {
+		} else if item.Kind == lexic.ItemCSqrt {
 			value = value + numA + item.GetValue()
 			return value
 		} else {
-			p.errorf("Expected %s found %s", lexic.ItemType["ItemDash"], item.GetType())
+			p.expected(item, lexic.ItemDash, lexic.ItemCSqrt)
 		}
-	} else if checkItemType(item, "__DASH__") {
+	} else if item.Kind == lexic.ItemDash {
 		dash := item.GetValue()
 		item = p.nextItem()
-		if checkItemType(item, "__CLOSE_SQRT__") {
+		if item.Kind == lexic.ItemCSqrt {
 			value = value + dash + item.GetValue()
 			return value
 		} else {
-			p.errorf("Expected %s found %s", lexic.ItemType["ItemCSqrt"], item.GetType())
+			p.expected(item, lexic.ItemCSqrt)
 		}
 	} else {
-		p.errorf("Expected %s or %s found %s", lexic.ItemType["ItemIntNumber"], lexic.ItemType["ItemDash"], item.GetType())
+		p.expected(item, lexic.ItemIntNumber, lexic.ItemDash)
 	}
 	value = value + item.GetValue()
 	return value
@@ -419,20 +514,24 @@ func (p *Parser) processHexRange() string {
 func (p *Parser) preocessHexOption() string {
 	value := "("
 	item := p.nextItem()
-	for !checkItemType(item, "__CLOSE_BRACKET__") {
+	for item.Kind != lexic.ItemCBracket {
 		switch {
-		case checkItemType(item, "__IDENTIFIER__"): // Hex value
+		case item.Kind == lexic.ItemIdentifier: // Hex value
+			value = value + item.GetValue()
+		case item.Kind == lexic.ItemIntNumber: // Number
 			value = value + item.GetValue()
-		case checkItemType(item, "__INT_NUMBER__"): // Number
+		case item.Kind == lexic.ItemQMark: // ? Wildcard
 			value = value + item.GetValue()
-		case checkItemType(item, "__QMAKR__"): // ? Wildcard
+		case item.Kind == lexic.ItemBitNot: // ~ Negation
 			value = value + item.GetValue()
-		case checkItemType(item, "__PIPE__"): // |
+		case item.Kind == lexic.ItemPipe: // |
 			value = value + item.GetValue()
-		case checkItemType(item, "__OPEN_SQRT__"): // [
+		case item.Kind == lexic.ItemOSqrt: // [
 			value = value + p.processHexRange()
+		case item.Kind == lexic.ItemOBracket: // Nested alternation
+			value = value + p.preocessHexOption()
 		default:
-			p.errorf("Expected one of %s, %s, %s, or %s and found %s", lexic.ItemType["ItemIdentifier"], lexic.ItemType["ItemIntNumber"], lexic.ItemType["ItemQMark"], lexic.ItemType["ItemPipe"], item.GetType())
+			p.expected(item, lexic.ItemIdentifier, lexic.ItemIntNumber, lexic.ItemQMark, lexic.ItemBitNot, lexic.ItemPipe, lexic.ItemOSqrt, lexic.ItemOBracket, lexic.ItemCBracket)
 		}
 		item = p.nextItem()
 	}
@@ -440,56 +539,27 @@ func (p *Parser) preocessHexOption() string {
 	return value
 }
 
-func (p *Parser) processCondition() string {
-	var last lexic.Item
-	value := ""
-	space := ""
+func (p *Parser) processCondition() (string, *Expr, *Span) {
+	items := p.conditionItems()
+	if len(items) == 0 {
+		return "", nil, nil
+	}
+	cond := newCondParser(p, items).parse()
+	p.log.Debugln("Condition: ", cond)
+	return cond.String(), cond, p.span(items[0], items[len(items)-1])
+}
+
+// conditionItems reads the items of a condition up to the closing curly
+// bracket, which is left as the last item read.
+func (p *Parser) conditionItems() []lexic.Item {
+	var items []lexic.Item
 	item := p.nextItem()
-	last = item
-	for !checkItemType(item, "__CLOSE_CURLY__") {
-		if checkItemType(last, "__DOT__") || checkItemType(last, "__DOT_DOT__") || checkItemType(last, "__AT__") {
-			space = ""
+	for item.Kind != lexic.ItemCCurly {
+		if item.Kind == lexic.ItemEOF || isSyncItem(item) {
+			p.expected(item, lexic.ItemCCurly)
 		}
-		if checkItemType(item, "__HASH__") {
-			hash := item.GetValue()
-			item = p.nextItem()
-			if checkItemType(item, "__IDENTIFIER__") || checkItemType(item, "__INT_NUMBER__") {
-				value += space + hash + item.GetValue()
-			} else {
-				p.errorf("Expected %s and found %s", lexic.ItemType["ItemIdentifier"], item.GetType())
-			}
-		} else if checkItemType(item, "__IDENTIFIER__") {
-			id := item.GetValue()
-			if checkItemType(p.peek(), "__DOT__") {
-				dot := p.nextItem()
-				if checkItemType(p.peek(), "__IDENTIFIER__") {
-					item := p.nextItem()
-					value += space + id + dot.GetValue() + item.GetValue()
-				} else {
-					p.errorf("Expected %d and found %s", lexic.ItemType["ItemIdentifier"], item.GetType())
-				}
-			} else {
-				value += space + id
-			}
-		} else if checkItemType(item, "__VARIABLE__") {
-			v := item.GetValue()
-			if checkItemType(p.peek(), "__STAR__") {
-				star := p.nextItem()
-				value += space + v + star.GetValue()
-			} else {
-				value += space + v
-			}
-		} else if checkItemType(item, "__DOT__") || checkItemType(item, "__DOT_DOT__") {
-			value += item.GetValue()
-		} else if checkItemType(item, "__STRING__") {
-			value += "\"" + item.GetValue() + "\""
-		} else {
-			value += space + item.GetValue()
-		}
-		space = " "
-		last = p.LastItem
+		items = append(items, item)
 		item = p.nextItem()
 	}
-	p.log.Debugln("Condition: ", value)
-	return value
+	return items
 }
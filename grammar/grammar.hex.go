@@ -0,0 +1,212 @@
+package grammar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of hex string tokens
+const (
+	HexByte        = "byte"        // 4D
+	HexMasked      = "masked"      // a byte with a nibble wildcard: 4? or ?D
+	HexWildcard    = "wildcard"    // ??
+	HexJump        = "jump"        // [Min-Max], Max is -1 when unbounded
+	HexAlternation = "alternation" // ( Alts[0] | Alts[1] | ... )
+)
+
+// hexMaxAlternationJump is the longest jump Yara allows inside alternatives
+const hexMaxAlternationJump = 200
+
+// HexToken is an element of a hex string. Bytes hold their two digits as
+// written in Value and are negated with ~ when Not is set.
+type HexToken struct {
+	Kind  string       `json:"kind"`
+	Value string       `json:"value,omitempty"`
+	Not   bool         `json:"not,omitempty"`
+	Min   int          `json:"min,omitempty"`
+	Max   int          `json:"max,omitempty"`
+	Alts  []HexPattern `json:"alts,omitempty"`
+}
+
+// HexPattern is the sequence of tokens of a hex string
+type HexPattern []HexToken
+
+// String renders the token as it is written in a hex string
+func (t HexToken) String() string {
+	switch t.Kind {
+	case HexJump:
+		switch {
+		case t.Max < 0 && t.Min == 0:
+			return "[-]"
+		case t.Max < 0:
+			return fmt.Sprintf("[%d-]", t.Min)
+		case t.Min == t.Max:
+			return fmt.Sprintf("[%d]", t.Min)
+		}
+		return fmt.Sprintf("[%d-%d]", t.Min, t.Max)
+	case HexAlternation:
+		alts := make([]string, len(t.Alts))
+		for i, alt := range t.Alts {
+			alts[i] = alt.String()
+		}
+		return "( " + strings.Join(alts, " | ") + " )"
+	}
+	if t.Not {
+		return "~" + t.Value
+	}
+	return t.Value
+}
+
+// String renders the tokens separated by spaces, without curly brackets
+func (hp HexPattern) String() string {
+	tokens := make([]string, len(hp))
+	for i, t := range hp {
+		tokens[i] = t.String()
+	}
+	return strings.Join(tokens, " ")
+}
+
+// ParseHex parses a hex string such as { 4D 5A ?? [2-4] ( 01 | 02 ) } and
+// checks it follows the rules of Yara: jumps must be ordered, they cannot
+// be the first or last token of the string or of an alternative, and
+// inside alternatives they must be bounded and up to 200 bytes long.
+func ParseHex(value string) (HexPattern, error) {
+	s := strings.TrimSpace(value)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("Hex string %s must be enclosed in curly brackets", value)
+	}
+	h := &hexScanner{input: strings.Join(strings.Fields(s[1:len(s)-1]), "")}
+	return h.sequence(false)
+}
+
+// hexScanner reads hex string tokens from input with the blanks removed
+type hexScanner struct {
+	input string
+	pos   int
+}
+
+// sequence scans tokens up to the end of the input or, inside an
+// alternative, up to the next | or closing bracket
+func (h *hexScanner) sequence(alternative bool) (HexPattern, error) {
+	var res HexPattern
+	for h.pos < len(h.input) {
+		var t HexToken
+		var err error
+		switch c := h.input[h.pos]; {
+		case c == '[':
+			t, err = h.jump(alternative)
+		case c == '(':
+			t, err = h.alternation()
+		case (c == '|' || c == ')') && alternative:
+			return res, checkHexEnds(res, "Alternative")
+		case c == '~':
+			h.pos++
+			t, err = h.byte()
+			if err == nil && t.Kind == HexWildcard {
+				err = fmt.Errorf("~?? is not allowed in hex strings")
+			}
+			t.Not = true
+		default:
+			t, err = h.byte()
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	if alternative {
+		return nil, fmt.Errorf("Expecting ) and found end of hex string")
+	}
+	return res, checkHexEnds(res, "Hex string")
+}
+
+func (h *hexScanner) byte() (HexToken, error) {
+	if h.pos+2 > len(h.input) || !isHexNibble(h.input[h.pos]) || !isHexNibble(h.input[h.pos+1]) {
+		return HexToken{}, fmt.Errorf("Illegal hex string value at %q", h.input[h.pos:])
+	}
+	value := h.input[h.pos : h.pos+2]
+	h.pos += 2
+	switch strings.Count(value, "?") {
+	case 2:
+		return HexToken{Kind: HexWildcard, Value: value}, nil
+	case 1:
+		return HexToken{Kind: HexMasked, Value: value}, nil
+	}
+	return HexToken{Kind: HexByte, Value: value}, nil
+}
+
+func (h *hexScanner) jump(alternative bool) (HexToken, error) {
+	end := strings.IndexByte(h.input[h.pos:], ']')
+	if end < 0 {
+		return HexToken{}, fmt.Errorf("Expecting ] and found end of hex string")
+	}
+	body := h.input[h.pos+1 : h.pos+end]
+	h.pos += end + 1
+
+	t := HexToken{Kind: HexJump, Max: -1}
+	bounds := strings.SplitN(body, "-", 2)
+	var err error
+	if bounds[0] != "" {
+		if t.Min, err = strconv.Atoi(bounds[0]); err != nil || t.Min < 0 {
+			return t, fmt.Errorf("Illegal jump [%s]", body)
+		}
+	}
+	switch {
+	case len(bounds) == 1:
+		t.Max = t.Min
+	case bounds[1] != "":
+		if t.Max, err = strconv.Atoi(bounds[1]); err != nil || t.Max < 0 {
+			return t, fmt.Errorf("Illegal jump [%s]", body)
+		}
+		if t.Max < t.Min {
+			return t, fmt.Errorf("Jump [%s] upper bound is lower than its lower bound", body)
+		}
+	}
+	if alternative && t.Max < 0 {
+		return t, fmt.Errorf("Unbounded jump [%s] is not allowed inside alternatives", body)
+	}
+	if alternative && t.Max > hexMaxAlternationJump {
+		return t, fmt.Errorf("Jump [%s] is longer than %d bytes, which is not allowed inside alternatives", body, hexMaxAlternationJump)
+	}
+	return t, nil
+}
+
+func (h *hexScanner) alternation() (HexToken, error) {
+	t := HexToken{Kind: HexAlternation}
+	h.pos++ // (
+	for {
+		alt, err := h.sequence(true)
+		if err != nil {
+			return t, err
+		}
+		t.Alts = append(t.Alts, alt)
+		c := h.input[h.pos]
+		h.pos++
+		if c == ')' {
+			break
+		}
+	}
+	if len(t.Alts) < 2 {
+		return t, fmt.Errorf("Alternation ( %s ) needs at least two alternatives", t.Alts[0])
+	}
+	return t, nil
+}
+
+// checkHexEnds reports empty sequences and sequences starting or ending
+// with a jump
+func checkHexEnds(hp HexPattern, what string) error {
+	switch {
+	case len(hp) == 0:
+		return fmt.Errorf("%s is empty", what)
+	case hp[0].Kind == HexJump:
+		return fmt.Errorf("%s cannot start with a jump", what)
+	case hp[len(hp)-1].Kind == HexJump:
+		return fmt.Errorf("%s cannot end with a jump", what)
+	}
+	return nil
+}
+
+func isHexNibble(c byte) bool {
+	return c == '?' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
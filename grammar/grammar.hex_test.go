@@ -0,0 +1,103 @@
+package grammar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func hexByte(v string) HexToken   { return HexToken{Kind: HexByte, Value: v} }
+func hexMasked(v string) HexToken { return HexToken{Kind: HexMasked, Value: v} }
+func hexJump(min, max int) HexToken {
+	return HexToken{Kind: HexJump, Min: min, Max: max}
+}
+func hexAlt(alts ...HexPattern) HexToken {
+	return HexToken{Kind: HexAlternation, Alts: alts}
+}
+
+var hexAny = HexToken{Kind: HexWildcard, Value: "??"}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		value string
+		want  HexPattern
+	}{
+		{"{ 4D 5A }", HexPattern{hexByte("4D"), hexByte("5A")}},
+		{"{4d5a\n90}", HexPattern{hexByte("4d"), hexByte("5a"), hexByte("90")}},
+		{"{ 4? ?D ?? }", HexPattern{hexMasked("4?"), hexMasked("?D"), hexAny}},
+		{"{ ~4D ~?A 00 }", HexPattern{{Kind: HexByte, Value: "4D", Not: true}, {Kind: HexMasked, Value: "?A", Not: true}, hexByte("00")}},
+		{"{ 4D [2] 5A }", HexPattern{hexByte("4D"), hexJump(2, 2), hexByte("5A")}},
+		{"{ 4D [2-4] 5A }", HexPattern{hexByte("4D"), hexJump(2, 4), hexByte("5A")}},
+		{"{ 4D [-] 5A }", HexPattern{hexByte("4D"), hexJump(0, -1), hexByte("5A")}},
+		{"{ 4D [3-] 5A }", HexPattern{hexByte("4D"), hexJump(3, -1), hexByte("5A")}},
+		{"{ 4D ( 01 | 02 03 | ?? ) 5A }", HexPattern{
+			hexByte("4D"),
+			hexAlt(HexPattern{hexByte("01")}, HexPattern{hexByte("02"), hexByte("03")}, HexPattern{hexAny}),
+			hexByte("5A"),
+		}},
+		{"{ 4D ( 01 ( 02 | 03 ) | 04 ) }", HexPattern{
+			hexByte("4D"),
+			hexAlt(HexPattern{hexByte("01"), hexAlt(HexPattern{hexByte("02")}, HexPattern{hexByte("03")})}, HexPattern{hexByte("04")}),
+		}},
+		{"{ 4D ( 01 [1-200] 02 | 03 ) }", HexPattern{
+			hexByte("4D"),
+			hexAlt(HexPattern{hexByte("01"), hexJump(1, 200), hexByte("02")}, HexPattern{hexByte("03")}),
+		}},
+	}
+	for _, test := range tests {
+		got, err := ParseHex(test.value)
+		if err != nil {
+			t.Errorf("ParseHex(%q): %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseHex(%q) = %+v, want %+v", test.value, got, test.want)
+		}
+		if again, err := ParseHex("{ " + got.String() + " }"); err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("ParseHex(%q) does not parse back from %s: %v", test.value, got, err)
+		}
+	}
+}
+
+func TestParseHexErrors(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"4D 5A", "Hex string 4D 5A must be enclosed in curly brackets"},
+		{"{ }", "Hex string is empty"},
+		{"{ 4G }", `Illegal hex string value at "4G"`},
+		{"{ 4D 5 }", `Illegal hex string value at "5"`},
+		{"{ ~?? }", "~?? is not allowed in hex strings"},
+		// jumps
+		{"{ [2] 4D }", "Hex string cannot start with a jump"},
+		{"{ 4D [2] }", "Hex string cannot end with a jump"},
+		{"{ 4D [4-2] 5A }", "Jump [4-2] upper bound is lower than its lower bound"},
+		{"{ 4D [a] 5A }", "Illegal jump [a]"},
+		{"{ 4D [1-b] 5A }", "Illegal jump [1-b]"},
+		{"{ 4D [2 5A }", "Expecting ] and found end of hex string"},
+		{"{ 4D ( 01 [-] 02 | 03 ) }", "Unbounded jump [-] is not allowed inside alternatives"},
+		{"{ 4D ( 01 [1-201] 02 | 03 ) }", "Jump [1-201] is longer than 200 bytes, which is not allowed inside alternatives"},
+		// alternatives
+		{"{ 4D ( | 01 ) }", "Alternative is empty"},
+		{"{ 4D ( 01 | ) }", "Alternative is empty"},
+		{"{ 4D ( 01 ) }", "Alternation ( 01 ) needs at least two alternatives"},
+		{"{ 4D ( 01 | 02 }", "Expecting ) and found end of hex string"},
+		{"{ 4D ( [1] 01 | 02 ) }", "Alternative cannot start with a jump"},
+		{"{ 4D ( 01 | 02 [1] ) }", "Alternative cannot end with a jump"},
+	}
+	for _, test := range tests {
+		_, err := ParseHex(test.value)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseHex(%q): error %v, want %s", test.value, err, test.want)
+		}
+	}
+}
+
+func TestHexStringError(t *testing.T) {
+	err := New("test.yar").Parse("rule r {\n strings:\n  $a = { 4D ( 01 | ) }\n condition: $a\n}")
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Parse: error %v, want a *ParseError", err)
+	}
+	if pe.Kind != SyntacticalError || pe.Line != 3 || !strings.HasSuffix(pe.Msg, "Alternative is empty") {
+		t.Errorf("Parse: %s error %v, want a syntactical error on line 3", pe.Kind, pe)
+	}
+}
@@ -0,0 +1,161 @@
+package grammar
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// SetIncludePaths sets the directories where included files are looked for
+// when they are not found next to the file including them
+func (p *Parser) SetIncludePaths(paths ...string) {
+	p.includePaths = paths
+}
+
+// ParseFile reads and parses fileName. Files it includes are looked for
+// relative to its directory first and then in the include paths.
+func (p *Parser) ParseFile(fileName string) error {
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	p.dir = filepath.Dir(fileName)
+	if abs, err := filepath.Abs(fileName); err == nil {
+		p.includes = append(p.includes, abs)
+	}
+	return p.Parse(string(src))
+}
+
+// processInclude parses the file named by an include directive, adding its
+// imports and rules to the ones of p. Files which cannot be found are
+// recorded in Diagnostics and skipped.
+func (p *Parser) processInclude() {
+	item := p.nextItem()
+	if item.Kind != lexic.ItemString {
+		p.expected(item, lexic.ItemString)
+	}
+	fileName, found := p.findInclude(item.GetValue())
+	if !found {
+		p.Diagnostics = append(p.Diagnostics, p.newError(IncludeError, item, fmt.Sprintf("Included file %s not found", item.GetValue())))
+		return
+	}
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		panic(p.newError(IncludeError, item, err.Error()))
+	}
+	for i, included := range p.includes {
+		if included == abs {
+			cycle := append(append([]string{}, p.includes[i:]...), abs)
+			panic(p.newError(IncludeError, item, "Include cycle: "+strings.Join(cycle, " -> ")))
+		}
+	}
+	p.log.Debugln("Including file: ", fileName)
+	sub, err := p.parseInclude(fileName, abs)
+	if sub != nil {
+		for _, imp := range sub.Imports {
+			if !p.moduleImported(imp) {
+				p.Imports = append(p.Imports, imp)
+			}
+		}
+		p.Diagnostics = append(p.Diagnostics, sub.Diagnostics...)
+	}
+	switch err.(type) {
+	case nil:
+	case *ParseError, *stopParsing:
+		panic(err)
+	default:
+		panic(p.newError(IncludeError, item, err.Error()))
+	}
+}
+
+// parseInclude parses an included file, or takes it from the include loader
+// when one is set, and adds its rules to the ones of p
+func (p *Parser) parseInclude(fileName, abs string) (*Parser, error) {
+	if p.loadInclude != nil {
+		sub, err := p.loadInclude(fileName)
+		if sub != nil {
+			for _, rule := range sub.Rules {
+				if p.recovery && p.ruleAlreadyImported(rule.Name) {
+					p.Diagnostics = append(p.Diagnostics, duplicateRule(rule))
+					continue
+				}
+				p.addRule(rule)
+			}
+		}
+		if sub != nil || err != nil {
+			return sub, err
+		}
+	}
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	sub := New(fileName)
+	sub.log = p.log
+	sub.recovery = p.recovery
+	sub.includePaths = p.includePaths
+	sub.includes = append(append([]string{}, p.includes...), abs)
+	sub.dir = filepath.Dir(fileName)
+	sub.loadInclude = p.loadInclude
+	sub.onRule = p.onRule
+	sub.Rules = p.Rules
+	sub.ruleNames = p.ruleNames
+	err = sub.Parse(string(src))
+	p.Rules = sub.Rules
+	return sub, err
+}
+
+// SetIncludeLoader makes the parser ask load for the files it includes,
+// with the path where they were found, before parsing them itself. load
+// returns the parsed file, whose rules, imports and diagnostics are added to
+// the ones of the parser, or a nil parser and error to let the parser read
+// the file. It allows included files to be parsed beforehand, for example
+// in parallel.
+func (p *Parser) SetIncludeLoader(load func(fileName string) (*Parser, error)) {
+	p.loadInclude = load
+}
+
+// IncludedFiles returns the files fileName includes, looked for the way
+// ParseFile does, in the order of the include directives. Files which cannot
+// be found are left out and the files they include in turn are not listed.
+func (p *Parser) IncludedFiles(fileName string) ([]string, error) {
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	finder := &Parser{dir: filepath.Dir(fileName), includePaths: p.includePaths}
+	items, _ := lexic.Tokenize(string(src)) // lexical errors are reported when parsing
+	var res []string
+	include := false
+	for _, item := range items {
+		if item.Kind == lexic.ItemComment {
+			continue
+		}
+		if include && item.Kind == lexic.ItemString {
+			if found, ok := finder.findInclude(item.GetValue()); ok {
+				res = append(res, found)
+			}
+		}
+		include = item.Kind == lexic.ItemKWInclude
+	}
+	return res, nil
+}
+
+// findInclude returns the path of an included file
+func (p *Parser) findInclude(name string) (string, bool) {
+	dirs := append([]string{p.dir}, p.includePaths...)
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	for _, dir := range dirs {
+		fileName := filepath.Join(dir, name)
+		if info, err := os.Stat(fileName); err == nil && !info.IsDir() {
+			return fileName, true
+		}
+	}
+	return "", false
+}
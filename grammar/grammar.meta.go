@@ -0,0 +1,111 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Map returns the meta entries as a map. When a key is repeated the last
+// value wins, as it did when meta was stored in a map.
+func (ml MetaList) Map() map[string]string {
+	m := make(map[string]string, len(ml))
+	for _, meta := range ml {
+		m[meta.Key] = meta.Value
+	}
+	return m
+}
+
+// Get returns every entry defined with key
+func (ml MetaList) Get(key string) MetaList {
+	var res MetaList
+	for _, meta := range ml {
+		if meta.Key == key {
+			res = append(res, meta)
+		}
+	}
+	return res
+}
+
+// UnmarshalJSON accepts the list of entries as well as the object used by
+// older versions of YaGo, whose keys are sorted since their order was lost.
+func (ml *MetaList) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var old map[string]string
+		if err := json.Unmarshal(data, &old); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(old))
+		for k := range old {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		*ml = nil
+		for _, k := range keys {
+			*ml = append(*ml, MetaDef{Key: k, Value: old[k], Typ: MetaString})
+		}
+		return nil
+	}
+	var list []MetaDef
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*ml = list
+	return nil
+}
+
+// metaJSON is the JSON form of a MetaDef, where Value keeps its type
+type metaJSON struct {
+	Key      string          `json:"key"`
+	Value    json.RawMessage `json:"value"`
+	Typ      int             `json:"type"`
+	Line     int             `json:"line,omitempty"`
+	Span     *Span           `json:"span,omitempty"`
+	Comments *Comments       `json:"comments,omitempty"`
+}
+
+// MarshalJSON encodes integer and boolean values as JSON numbers and
+// booleans. Integers not written in decimal, such as 0x10, stay strings.
+func (m MetaDef) MarshalJSON() ([]byte, error) {
+	value := []byte(m.Value)
+	_, err := strconv.ParseInt(m.Value, 10, 64)
+	if !(m.Typ == MetaInt && err == nil) && m.Typ != MetaBool {
+		value, _ = json.Marshal(m.Value)
+	}
+	return json.Marshal(metaJSON{Key: m.Key, Value: value, Typ: m.Typ, Line: m.Line, Span: m.Span, Comments: m.Comments})
+}
+
+// UnmarshalJSON decodes a meta entry, taking its type from the JSON value
+// when it is a number or a boolean.
+func (m *MetaDef) UnmarshalJSON(data []byte) error {
+	var j metaJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	m.Key, m.Typ, m.Line, m.Span, m.Comments = j.Key, j.Typ, j.Line, j.Span, j.Comments
+
+	var value interface{}
+	dec := json.NewDecoder(bytes.NewReader(j.Value))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case string:
+		m.Value = v
+		if m.Typ == MetaType {
+			m.Typ = MetaString
+		}
+	case json.Number:
+		m.Value = v.String()
+		m.Typ = MetaInt
+	case bool:
+		m.Value = strconv.FormatBool(v)
+		m.Typ = MetaBool
+	default:
+		return fmt.Errorf("Unsupported value for meta %s: %s", j.Key, j.Value)
+	}
+	return nil
+}
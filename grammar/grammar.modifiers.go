@@ -0,0 +1,164 @@
+package grammar
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// Modifier is a string modifier such as nocase or xor. Args holds the
+// arguments as written: the key or the min and max keys of xor, and the
+// escaped custom alphabet of base64 and base64wide.
+type Modifier struct {
+	Name string   `json:"name"`
+	Args []string `json:"args,omitempty"`
+}
+
+// String renders the modifier as it is written in a rule
+func (m Modifier) String() string {
+	switch {
+	case len(m.Args) == 0:
+		return m.Name
+	case m.Name == "xor":
+		return m.Name + "(" + strings.Join(m.Args, "-") + ")"
+	}
+	return m.Name + "(\"" + m.Args[0] + "\")"
+}
+
+// UnmarshalJSON also accepts modifiers written as plain strings, as older
+// versions of YaGo did
+func (m *Modifier) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("\"")) {
+		*m = Modifier{}
+		return json.Unmarshal(data, &m.Name)
+	}
+	type modifier Modifier
+	return json.Unmarshal(data, (*modifier)(m))
+}
+
+// modifiersAllowed lists the modifiers each type of string accepts
+var modifiersAllowed = map[int][]string{
+	StringString: {"nocase", "ascii", "wide", "fullword", "private", "xor", "base64", "base64wide"},
+	StringRegex:  {"nocase", "ascii", "wide", "fullword", "private"},
+	StringHex:    {"private"},
+}
+
+// modifiersExcluded lists the modifiers which cannot be used together
+var modifiersExcluded = map[string][]string{
+	"xor":        {"nocase", "base64", "base64wide"},
+	"base64":     {"nocase", "fullword", "xor"},
+	"base64wide": {"nocase", "fullword", "xor"},
+}
+
+func (p *Parser) processStringModifiers(typ int) []Modifier {
+	var mods []Modifier
+	for isStringModifier(p.peek()) {
+		item := p.nextItem()
+		mod := Modifier{Name: item.GetValue()}
+		if p.peek().Kind == lexic.ItemOBracket {
+			switch mod.Name {
+			case "xor":
+				mod.Args = p.processXorArgs()
+			case "base64", "base64wide":
+				mod.Args = p.processBase64Args()
+			default:
+				p.errorAt(p.peek(), "Modifier %s does not take arguments", mod.Name)
+			}
+		}
+		mods = append(mods, mod)
+	}
+	p.checkModifiers(typ, mods)
+	return mods
+}
+
+// processXorArgs parses the (key) or (min-max) arguments of xor
+func (p *Parser) processXorArgs() []string {
+	p.nextItem() // (
+	var args []string
+	var keys []int
+	for {
+		item := p.nextItem()
+		if item.Kind != lexic.ItemIntNumber {
+			p.expected(item, lexic.ItemIntNumber)
+		}
+		key := item.Int
+		if key < 0 || key > 255 {
+			p.errorf("xor key %s out of range (0-255)", item.GetValue())
+		}
+		args = append(args, item.GetValue())
+		keys = append(keys, int(key))
+
+		item = p.nextItem()
+		if item.Kind == lexic.ItemCBracket {
+			break
+		}
+		if len(args) == 2 || item.Kind != lexic.ItemDash {
+			p.expected(item, lexic.ItemCBracket)
+		}
+	}
+	if len(keys) == 2 && keys[0] > keys[1] {
+		p.errorf("xor lower bound %s exceeds upper bound %s", args[0], args[1])
+	}
+	return args
+}
+
+// processBase64Args parses the ("alphabet") argument of base64 and base64wide
+func (p *Parser) processBase64Args() []string {
+	p.nextItem() // (
+	item := p.nextItem()
+	if item.Kind != lexic.ItemString {
+		p.expected(item, lexic.ItemString)
+	}
+	alphabet := item.GetValue()
+	if n := len(item.Decoded); n != 64 {
+		p.errorf("base64 alphabet must be 64 bytes long, found %d", n)
+	}
+	if item = p.nextItem(); item.Kind != lexic.ItemCBracket {
+		p.expected(item, lexic.ItemCBracket)
+	}
+	return []string{alphabet}
+}
+
+// checkModifiers reports the modifiers which are repeated, not allowed on
+// the type of string or not allowed together
+func (p *Parser) checkModifiers(typ int, mods []Modifier) {
+	seen := map[string]bool{}
+	for _, m := range mods {
+		if seen[m.Name] {
+			p.errorf("Duplicated modifier %s", m.Name)
+		}
+		seen[m.Name] = true
+		if !inList(modifiersAllowed[typ], m.Name) {
+			p.errorf("Modifier %s is not allowed on %s strings", m.Name, stringTypeName(typ))
+		}
+	}
+	for _, m := range mods {
+		for _, other := range modifiersExcluded[m.Name] {
+			if seen[other] {
+				p.errorf("Modifiers %s and %s cannot be used together", m.Name, other)
+			}
+		}
+	}
+}
+
+// stringTypeName returns the name of a type of string in error messages
+func stringTypeName(typ int) string {
+	switch typ {
+	case StringRegex:
+		return "regex"
+	case StringHex:
+		return "hex"
+	}
+	return "text"
+}
+
+func inList(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
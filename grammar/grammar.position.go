@@ -0,0 +1,43 @@
+package grammar
+
+import (
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// Position is a location in a rule file. Lines and columns start at 1 and
+// Offset is the number of bytes from the beginning of the file. Column
+// counts bytes and RuneColumn characters.
+type Position struct {
+	Line       int `json:"line"`
+	Column     int `json:"column"`
+	RuneColumn int `json:"rune_column,omitempty"`
+	Offset     int `json:"offset"`
+}
+
+// Span is the part of a rule file a definition was read from. End is the
+// position right after its last character.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// span returns the span going from the first to the last item
+func (p *Parser) span(first, last lexic.Item) *Span {
+	_, end := p.itemOffsets(last)
+	return &Span{
+		Start: *p.position(first),
+		End:   Position{Line: last.EndLine, Column: last.EndColumn, RuneColumn: last.EndRuneColumn, Offset: end},
+	}
+}
+
+// position returns where item starts
+func (p *Parser) position(item lexic.Item) *Position {
+	start, _ := p.itemOffsets(item)
+	return &Position{Line: item.Line, Column: item.Column, RuneColumn: item.RuneColumn, Offset: start}
+}
+
+// itemOffsets returns where item starts and ends, including the quotes of
+// text strings which are not part of their value
+func (p *Parser) itemOffsets(item lexic.Item) (int, int) {
+	return item.Span()
+}
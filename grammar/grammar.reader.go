@@ -0,0 +1,47 @@
+package grammar
+
+import (
+	"io"
+
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// stopParsing is raised when the function given to ParseReader returns an
+// error, which is the one ParseReader returns
+type stopParsing struct {
+	err error
+}
+
+func (s *stopParsing) Error() string {
+	return s.err.Error()
+}
+
+// ParseReader parses the rules read from r, calling onRule with each rule
+// as soon as it is parsed. See Parser.ParseReader.
+func ParseReader(r io.Reader, onRule func(RuleDef) error) error {
+	return New("").ParseReader(r, onRule)
+}
+
+// ParseReader parses the rules read from r like Parse, but calls onRule
+// with each rule as soon as it is parsed, rules of included files too,
+// instead of keeping them in Rules. Only the input of the statement being
+// parsed is held, so the memory used does not grow with the size of the
+// input. Parsing stops at the first error returned by onRule, which is
+// returned as is, as are errors reading r.
+func (p *Parser) ParseReader(r io.Reader, onRule func(RuleDef) error) (err error) {
+	p.log.Debugln(" ** Stating parser **")
+	p.onRule = onRule
+	defer func() {
+		if readErr := p.Lex.Err(); readErr != nil {
+			err = readErr
+		} else if stop, ok := err.(*stopParsing); ok {
+			err = stop.err
+		}
+		p.onRule = nil
+	}()
+	defer p.recover(&err)
+	p.Lex = lexic.NewReader(p.Name, r)
+	p.parse()
+	p.log.Debugln(" ** Parser finished **")
+	return nil
+}
@@ -0,0 +1,25 @@
+package grammar
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReaderDuplicateRule(t *testing.T) {
+	src := "rule a { condition: true }\nrule b { condition: a }\nrule a { condition: false }\n"
+	var names []string
+	err := ParseReader(strings.NewReader(src), func(rule RuleDef) error {
+		names = append(names, rule.Name)
+		return nil
+	})
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("ParseReader: error %v, want a *ParseError", err)
+	}
+	if pe.Line != 3 || !strings.Contains(pe.Msg, "a alredy defined") {
+		t.Errorf("ParseReader: error %v, want rule a already defined on line 3", pe)
+	}
+	if strings.Join(names, ",") != "a,b" {
+		t.Errorf("ParseReader: rules %v, want a and b", names)
+	}
+}
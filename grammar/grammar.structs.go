@@ -13,6 +13,14 @@ const (
 	StringHex
 )
 
+// Types of meta values
+const (
+	MetaType = iota
+	MetaString
+	MetaInt
+	MetaBool
+)
+
 // Parser represents the Yara rules
 type Parser struct {
 	Name      string         `json:"file_name"`
@@ -23,24 +31,71 @@ type Parser struct {
 	token     [2]lexic.Item  `json:"-"` // two-token lookahead for parser.
 	Imports   []string       `json:"imports"`
 	Rules     []RuleDef      `json:"rules"`
+	Comments  *Comments      `json:"comments,omitempty"`
 	log       *logrus.Logger `json:"-"`
+	recovery  bool           `json:"-"` // keep parsing after an error
+	rule      string         `json:"-"` // name of the rule being parsed
+
+	dir          string   // directory of the file being parsed
+	includePaths []string // where else included files are looked for
+	includes     []string // absolute paths of the files being parsed, to detect cycles
+	loadInclude  func(fileName string) (*Parser, error)
+	onRule       func(RuleDef) error // called with each rule instead of adding it to Rules
+	ruleNames    map[string]struct{} // rules parsed so far, even when not kept in Rules
+
+	comments []*comment  // comments read but not attached yet
+	fetched  bool        // an item has been read
+	prevEnd  int         // where the last item read ends
+	prevLine int         // line of the last item read
+	lexErr   *ParseError // lexical error of the item read by lookAhead
+	ruleSeen bool        // a rule has been parsed
+
+	Diagnostics []*ParseError `json:"diagnostics,omitempty"`
 }
 
-// StringDef defines a string variable
+// StringDef defines a string variable. Regular expressions are kept as
+// written in Value and split into Body and Flags, hex strings are parsed
+// into Hex. Text strings are kept escaped in Value and decoded in Bytes.
 type StringDef struct {
-	Name      string   `json:"name"`
-	Value     string   `json:"value"`
-	Modifiers []string `json:"modifers"`
-	Typ       int      `json:"type"`
+	Name      string     `json:"name"`
+	Value     string     `json:"value"`
+	Bytes     []byte     `json:"bytes,omitempty"`
+	Modifiers []Modifier `json:"modifers"`
+	Typ       int        `json:"type"`
+	Body      string     `json:"body,omitempty"`
+	Flags     string     `json:"flags,omitempty"`
+	Hex       HexPattern `json:"hex,omitempty"`
+	Span      *Span      `json:"span,omitempty"`
+	Comments  *Comments  `json:"comments,omitempty"`
 }
 
+// MetaDef defines a meta entry, Value holds the text as written
+type MetaDef struct {
+	Key      string    `json:"key"`
+	Value    string    `json:"value"`
+	Typ      int       `json:"type"`
+	Line     int       `json:"line,omitempty"`
+	Span     *Span     `json:"span,omitempty"`
+	Comments *Comments `json:"comments,omitempty"`
+}
+
+// MetaList holds the meta entries of a rule in declaration order
+type MetaList []MetaDef
+
 // RuleDef defines a yara rule
 type RuleDef struct {
-	Name      string            `json:"name"`
-	Global    bool              `json:"global"`
-	Private   bool              `json:"private"`
-	Tags      []string          `json:"tags"`
-	Meta      map[string]string `json:"meta"`
-	Strings   []StringDef       `json:"strings"`
-	Condition string            `json:"condition"`
+	Name      string      `json:"name"`
+	File      string      `json:"file,omitempty"` // file the rule was read from
+	Global    bool        `json:"global"`
+	Private   bool        `json:"private"`
+	Tags      []string    `json:"tags"`
+	Meta      MetaList    `json:"meta"`
+	Strings   []StringDef `json:"strings"`
+	Condition string      `json:"condition"`
+
+	ConditionAST  *Expr `json:"condition_ast,omitempty"`
+	ConditionSpan *Span `json:"condition_span,omitempty"`
+	Span          *Span `json:"span,omitempty"`
+
+	Comments *Comments `json:"comments,omitempty"`
 }
@@ -0,0 +1,232 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validate runs the semantic checks Yara does when compiling rules: strings
+// and rules referenced in conditions must be defined, rules before the rule
+// referencing them, modules must be imported and every string must be used
+// unless its name starts with $_. Wildcards such as $a* must match at least
+// one string. Loop variables must be unique, as many as the values the loop
+// iterates over and loops up to 4 levels deep. It returns the problems
+// found, in the order of the rules.
+func Validate(imports []string, rules []RuleDef) []*ParseError {
+	v := &validator{
+		imports: map[string]bool{},
+		rules:   map[string]int{},
+	}
+	for _, imp := range imports {
+		v.imports[imp] = true
+	}
+	for i, rule := range rules {
+		if _, ok := v.rules[rule.Name]; !ok {
+			v.rules[rule.Name] = i
+		}
+	}
+	for i, rule := range rules {
+		v.index, v.rule = i, rule
+		v.used = map[string]bool{}
+		if rule.ConditionAST != nil {
+			v.expr(rule.ConditionAST, nil, false)
+		}
+		for _, str := range rule.Strings {
+			if !v.used[str.Name] && !strings.HasPrefix(str.Name, "$_") {
+				v.errorAt(UnusedString, positionOf(str.Span), "String %s is not used in the condition", str.Name)
+			}
+		}
+	}
+	return v.diags
+}
+
+// maxLoopNesting is how deep Yara lets loops be nested
+const maxLoopNesting = 4
+
+// validator holds the state of Validate while it walks the rules
+type validator struct {
+	imports map[string]bool
+	rules   map[string]int // index of each rule
+	index   int            // index of the rule being checked
+	rule    RuleDef
+	used    map[string]bool // strings of the rule referenced so far
+	depth   int             // number of loops around the expression checked
+	diags   []*ParseError
+}
+
+// expr checks e. vars holds the loop variables in scope and anonymous tells
+// whether anonymous string references such as $ or # are allowed, which
+// happens inside the body of for..of loops.
+func (v *validator) expr(e *Expr, vars map[string]bool, anonymous bool) {
+	switch e.Kind {
+	case ExprString, ExprCount, ExprOffset, ExprLength:
+		v.stringRef(e, vars, anonymous)
+	case ExprKeyword:
+		if e.Value == "them" {
+			v.wildcard(e, "$*")
+		}
+	case ExprIdentifier:
+		v.ruleRef(e, vars)
+		return
+	case ExprMember, ExprIndex, ExprCall:
+		v.moduleRef(e, vars, anonymous)
+		return
+	case ExprForOf, ExprForIn:
+		v.expr(e.Args[0], vars, anonymous)
+		v.expr(e.Args[1], vars, anonymous)
+		if v.depth == maxLoopNesting {
+			v.errorAt(LoopNesting, e.Pos, "Loops are nested more than %d levels deep", maxLoopNesting)
+		}
+		v.depth++
+		if e.Kind == ExprForOf {
+			v.expr(e.Args[2], vars, true)
+		} else {
+			v.expr(e.Args[2], v.loopVars(e, vars), anonymous)
+		}
+		v.depth--
+		return
+	}
+	for _, a := range e.Args {
+		v.expr(a, vars, anonymous)
+	}
+}
+
+// loopVars checks the variables of a for..in loop and returns the variables
+// in scope in its body. Ranges and enumerations yield one value on each
+// iteration, arrays one and dictionaries two.
+func (v *validator) loopVars(e *Expr, vars map[string]bool) map[string]bool {
+	scope := map[string]bool{}
+	for name := range vars {
+		scope[name] = true
+	}
+	for _, name := range e.Vars {
+		if scope[name] {
+			v.errorAt(LoopVariable, e.Pos, "Duplicated loop variable %s", name)
+		}
+		scope[name] = true
+	}
+	switch iterable := e.Args[1]; {
+	case (iterable.Kind == ExprRange || iterable.Kind == ExprSet) && len(e.Vars) != 1:
+		v.errorAt(LoopVariable, e.Pos, "Loop over a range or an enumeration takes one variable, found %d", len(e.Vars))
+	case len(e.Vars) > 2:
+		v.errorAt(LoopVariable, e.Pos, "Loop over an array or a dictionary takes one or two variables, found %d", len(e.Vars))
+	}
+	return scope
+}
+
+// stringRef checks a reference to a string such as $a, #a, @a[1] or $a*
+func (v *validator) stringRef(e *Expr, vars map[string]bool, anonymous bool) {
+	name := "$" + e.Value[1:]
+	switch {
+	case name == "$":
+		if !anonymous {
+			v.errorAt(UndefinedString, e.Pos, "Anonymous string reference %s used outside of a for..of loop", e.Value)
+		}
+	case strings.HasSuffix(name, "*"):
+		v.wildcard(e, name)
+	case v.stringDefined(name):
+		v.used[name] = true
+	default:
+		v.errorAt(UndefinedString, e.Pos, "Undefined string identifier %s", e.Value)
+	}
+	for _, a := range e.Args {
+		v.expr(a, vars, anonymous)
+	}
+}
+
+// wildcard marks the strings matching pattern as used
+func (v *validator) wildcard(e *Expr, pattern string) {
+	prefix := strings.TrimSuffix(pattern, "*")
+	found := false
+	for _, str := range v.rule.Strings {
+		if strings.HasPrefix(str.Name, prefix) {
+			v.used[str.Name] = true
+			found = true
+		}
+	}
+	if !found {
+		v.errorAt(UndefinedString, e.Pos, "No string matches %s", e.Value)
+	}
+}
+
+func (v *validator) stringDefined(name string) bool {
+	for _, str := range v.rule.Strings {
+		if str.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleRef checks an identifier used on its own, which must be a loop
+// variable or a rule defined before the current one. Inside a set of
+// rules it may also be a wildcard such as Rule*.
+func (v *validator) ruleRef(e *Expr, vars map[string]bool) {
+	name := e.Value
+	switch {
+	case vars[name] || isIntFunctionName(name):
+	case strings.HasSuffix(name, "*"):
+		prefix := strings.TrimSuffix(name, "*")
+		for rule, i := range v.rules {
+			if strings.HasPrefix(rule, prefix) && i < v.index {
+				return
+			}
+		}
+		v.errorAt(UndefinedRule, e.Pos, "No rule defined before %s matches %s", v.rule.Name, name)
+	default:
+		i, ok := v.rules[name]
+		switch {
+		case !ok && v.imports[name]:
+		case !ok:
+			v.errorAt(UndefinedRule, e.Pos, "Undefined identifier %s", name)
+		case i >= v.index:
+			v.errorAt(RuleOrder, e.Pos, "Rule %s is referenced before being defined", name)
+		}
+	}
+}
+
+// moduleRef checks member accesses, indexing and function calls, whose
+// root must be an imported module, a loop variable or a built-in function
+func (v *validator) moduleRef(e *Expr, vars map[string]bool, anonymous bool) {
+	root := e
+	for root.Kind == ExprMember || root.Kind == ExprIndex || root.Kind == ExprCall {
+		for _, a := range root.Args[1:] {
+			v.expr(a, vars, anonymous)
+		}
+		root = root.Args[0]
+	}
+	if root.Kind != ExprIdentifier {
+		v.expr(root, vars, anonymous)
+		return
+	}
+	if !vars[root.Value] && !isIntFunctionName(root.Value) && !v.imports[root.Value] {
+		v.errorAt(UndefinedModule, root.Pos, "Module %s is not imported", root.Value)
+	}
+}
+
+// errorAt records a semantic error of the current rule at pos, or at the
+// condition of the rule when pos is unknown
+func (v *validator) errorAt(code string, pos *Position, format string, args ...interface{}) {
+	if pos == nil {
+		pos = positionOf(v.rule.ConditionSpan)
+	}
+	err := &ParseError{
+		Kind:     SemanticError,
+		Code:     code,
+		FileName: v.rule.File,
+		Rule:     v.rule.Name,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+	if pos != nil {
+		err.Line, err.Column, err.RuneColumn, err.Offset = pos.Line, pos.Column, pos.RuneColumn, pos.Offset
+	}
+	v.diags = append(v.diags, err)
+}
+
+// positionOf returns where span starts, or nil when it is unknown
+func positionOf(span *Span) *Position {
+	if span == nil {
+		return nil
+	}
+	return &span.Start
+}
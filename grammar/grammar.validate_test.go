@@ -0,0 +1,92 @@
+package grammar
+
+import (
+	"fmt"
+	"testing"
+)
+
+// diagnose parses src and returns the diagnostics of the parser followed by
+// those of Validate, as yago check does
+func diagnose(t *testing.T, src string) []*ParseError {
+	t.Helper()
+	p := New("test.yar")
+	p.SetRecovery(true)
+	if err := p.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return append(p.Diagnostics, Validate(p.Imports, p.Rules)...)
+}
+
+func TestValidateCodes(t *testing.T) {
+	tests := []struct {
+		code, severity string
+		src            string
+		pos            string // line:column:offset of the diagnostic
+	}{
+		{UndefinedString, "", "rule r {\n condition: $a\n}", "2:13:21"},
+		{UndefinedString, "", "rule r {\n strings: $a = \"x\"\n condition: $a and any of ($b*)\n}", "3:28:55"},
+		{UndefinedString, "", "rule r {\n strings: $a = \"x\"\n condition: $ and $a\n}", "3:13:40"},
+		{UnusedString, "", "rule r {\n strings:\n  $a = \"x\"\n  $b = \"y\"\n condition: $a\n}", "4:3:32"},
+		{UndefinedRule, "", "rule r {\n condition: other\n}", "2:13:21"},
+		{RuleOrder, "", "rule a {\n condition: b\n}\nrule b {\n condition: true\n}", "2:13:21"},
+		{UndefinedModule, "", "rule r {\n condition: pe.is_dll()\n}", "2:13:21"},
+		{LoopVariable, "", "rule r {\n condition: for any i, j in (1..2) : (true)\n}", "2:13:21"},
+		{LoopVariable, "", "rule r {\n condition: for any i in (1..2) : (for any i in (1..2) : (true))\n}", "2:36:44"},
+		{LoopNesting, "", "rule r {\n condition: " +
+			"for any a in (1..2) : (for any b in (1..2) : (for any c in (1..2) : (" +
+			"for any d in (1..2) : (for any e in (1..2) : (true)))))\n}", "2:105:113"},
+		{DuplicateImport, SeverityWarning, "import \"pe\"\nimport \"pe\"\nrule r {\n condition: pe.is_dll()\n}", "2:8:19"},
+	}
+	for _, test := range tests {
+		diags := diagnose(t, test.src)
+		if len(diags) != 1 {
+			t.Errorf("%s: %d diagnostics %v, want 1", test.code, len(diags), diags)
+			continue
+		}
+		d := diags[0]
+		pos := fmt.Sprintf("%d:%d:%d", d.Line, d.Column, d.Offset)
+		if d.Code != test.code || d.Severity != test.severity || pos != test.pos {
+			t.Errorf("%s: got %s %q at %s (%v), want %s %q at %s", test.code, d.Code, d.Severity, pos, d, test.code, test.severity, test.pos)
+		}
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	src := `import "pe"
+rule a { condition: true }
+rule b {
+	strings:
+		$a = "x"
+		$_unused = "y"
+		$c1 = "z"
+	condition:
+		a and $a and any of ($c*) and pe.is_dll() and
+		for all i in (1..#a) : (@a[i] > 0) and
+		for any of ($a, $c1) : ($ at 0)
+}`
+	if diags := diagnose(t, src); len(diags) != 0 {
+		t.Errorf("diagnostics %v, want none", diags)
+	}
+}
+
+func TestModifierErrors(t *testing.T) {
+	tests := []struct{ modifiers, want string }{
+		{"ascii ascii", "2:28: Duplicated modifier ascii"},
+		{"nocase xor", "2:29: Modifiers xor and nocase cannot be used together"},
+		{"ascii(1)", "2:27: Modifier ascii does not take arguments"},
+		{"xor(256)", "2:26: xor key 256 out of range (0-255)"},
+		{"xor(5-2)", "2:29: xor lower bound 5 exceeds upper bound 2"},
+		{`base64("abc")`, "2:29: base64 alphabet must be 64 bytes long, found 3"},
+		{"private private", "2:30: Duplicated modifier private"},
+	}
+	for _, test := range tests {
+		diags := diagnose(t, "rule r {\n strings: $a = \"abc\" "+test.modifiers+"\n condition: $a\n}")
+		if len(diags) != 1 {
+			t.Errorf("%s: %d diagnostics %v, want 1", test.modifiers, len(diags), diags)
+			continue
+		}
+		if d := diags[0]; d.Kind != SyntacticalError || d.Code != "" || d.Error() != "test.yar:"+test.want {
+			t.Errorf("%s: %s error %v, want syntactical error %s", test.modifiers, d.Kind, d, test.want)
+		}
+	}
+}
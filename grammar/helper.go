@@ -1,18 +1,59 @@
 package grammar
 
 import (
-	"reflect"
+	"strings"
 
 	"github.com/Yara-Rules/yago/lexic"
 )
 
 func isStringModifier(a lexic.Item) bool {
-	return a.GetType() == "__KW_NOCASE__" || a.GetType() == "__KW_ASCII__" ||
-		a.GetType() == "__KW_WIDE__" || a.GetType() == "__KW_FULLWORD__"
+	switch a.Kind {
+	case lexic.ItemKWNocase, lexic.ItemKWAscii, lexic.ItemKWWide, lexic.ItemKWFullword,
+		lexic.ItemKWPrivate, lexic.ItemKWXor, lexic.ItemKWBase64, lexic.ItemKWBase64wide:
+		return true
+	}
+	return false
+}
+
+// isSyncItem reports whether item may start a top level statement
+func isSyncItem(item lexic.Item) bool {
+	switch item.Kind {
+	case lexic.ItemKWRule, lexic.ItemKWPrivate, lexic.ItemKWGlobal, lexic.ItemKWImport, lexic.ItemKWInclude:
+		return true
+	}
+	return false
+}
+
+// isIntFunction reports whether item is one of the intXX/uintXX functions
+func isIntFunction(item lexic.Item) bool {
+	switch item.Kind {
+	case lexic.ItemKWInt8, lexic.ItemKWInt16, lexic.ItemKWInt32,
+		lexic.ItemKWInt8be, lexic.ItemKWInt16be, lexic.ItemKWInt32be,
+		lexic.ItemKWUint8, lexic.ItemKWUint16, lexic.ItemKWUint32,
+		lexic.ItemKWUint8be, lexic.ItemKWUint16be, lexic.ItemKWUint32be:
+		return true
+	}
+	return false
 }
 
-func checkItemType(item lexic.Item, itemType string) bool {
-	return lexic.ItemType[reflect.TypeOf(item).Name()] == itemType
+// isIntFunctionName reports whether name is one of the intXX/uintXX functions
+func isIntFunctionName(name string) bool {
+	switch strings.TrimPrefix(name, "u") {
+	case "int8", "int16", "int32", "int8be", "int16be", "int32be":
+		return true
+	}
+	return false
+}
+
+// metaType returns the type of a meta value item
+func metaType(item lexic.Item) int {
+	switch {
+	case item.Kind == lexic.ItemIntNumber:
+		return MetaInt
+	case item.Kind == lexic.ItemKWTrue || item.Kind == lexic.ItemKWFalse:
+		return MetaBool
+	}
+	return MetaString
 }
 
 func stringDefined(stringTable []string, item lexic.Item) bool {
@@ -26,3 +67,13 @@ func stringDefined(stringTable []string, item lexic.Item) bool {
 	}
 	return false
 }
+
+// splitRegex splits a regular expression such as /abc/is into its body and
+// its flags
+func splitRegex(value string) (string, string) {
+	end := strings.LastIndex(value, "/")
+	if !strings.HasPrefix(value, "/") || end < 1 {
+		return value, ""
+	}
+	return value[1:end], value[end+1:]
+}
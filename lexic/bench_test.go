@@ -0,0 +1,40 @@
+package lexic
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// benchRuleset returns the ruleset the lexer and parser benchmarks share
+func benchRuleset(b *testing.B) string {
+	src, err := ioutil.ReadFile(filepath.Join("..", "testdata", "bench.yar"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return string(src)
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	input := benchRuleset(b)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Tokenize(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	input := benchRuleset(b)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := Lex("", input)
+		for range l.Items {
+		}
+	}
+}
@@ -1,28 +1,6 @@
 package lexic
 
-import (
-	"fmt"
-	"os"
-	"unicode"
-)
-
-// checkErr checks if error has happend
-func checkErr(err error) {
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-}
-
-// isKeyword reports wheter w is a yara keyword
-func isKeyword(w string) bool {
-	for _, kw := range kwList {
-		if kw == w {
-			return true
-		}
-	}
-	return false
-}
+import "unicode"
 
 // isColon reports whether r is a colon
 func isColon(r rune) bool {
@@ -49,6 +27,21 @@ func isQuote(r rune) bool {
 	return r == '"'
 }
 
+// isDigit reports whether r is a decimal digit
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// isDecimal reports whether s is made of decimal digits only
+func isDecimal(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(rune(s[i])) {
+			return false
+		}
+	}
+	return s != ""
+}
+
 // isHexChar reports whether r is a hex character
 func isHexChar(r rune) bool {
 	return r == '0' || r == '1' || r == '2' || r == '3' || r == '4' ||
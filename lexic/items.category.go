@@ -0,0 +1,78 @@
+package lexic
+
+// Category groups the kinds of tokens for syntax highlighting
+type Category int
+
+// Categories of tokens
+const (
+	CategoryNone       Category = iota // EOF
+	CategoryKeyword                    // rule, condition, and, nocase...
+	CategoryIdentifier                 // rule names, modules and string identifiers
+	CategoryString                     // text strings
+	CategoryRegex                      // regular expressions
+	CategoryHex                        // hex strings, braces included
+	CategoryNumber                     // numbers, such as 10, 0x5a4d or 200KB
+	CategoryOperator                   // operators and punctuation
+	CategoryComment                    // comments
+	CategoryWhitespace                 // blanks
+	CategoryInvalid                    // lexical errors and the text they skip
+)
+
+var categoryNames = [...]string{
+	CategoryNone:       "none",
+	CategoryKeyword:    "keyword",
+	CategoryIdentifier: "identifier",
+	CategoryString:     "string",
+	CategoryRegex:      "regex",
+	CategoryHex:        "hex",
+	CategoryNumber:     "number",
+	CategoryOperator:   "operator",
+	CategoryComment:    "comment",
+	CategoryWhitespace: "whitespace",
+	CategoryInvalid:    "invalid",
+}
+
+// String returns the name of c, such as keyword or comment
+func (c Category) String() string {
+	if c < 0 || int(c) >= len(categoryNames) {
+		return "none"
+	}
+	return categoryNames[c]
+}
+
+// MarshalText writes c as its name, so it can be used in JSON
+func (c Category) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// kindCategory returns the category of a token of kind k and the given
+// value, out of hex strings
+func kindCategory(k Kind, value string) Category {
+	switch k {
+	case ItemEOF:
+		return CategoryNone
+	case ItemError, ItemInvalid:
+		return CategoryInvalid
+	case ItemComment:
+		return CategoryComment
+	case ItemSpace:
+		return CategoryWhitespace
+	case ItemString:
+		return CategoryString
+	case ItemRegex:
+		return CategoryRegex
+	case ItemIntNumber, ItemFloatNumber:
+		return CategoryNumber
+	case ItemIdentifier:
+		if len(value) > 0 && value[0] >= '0' && value[0] <= '9' {
+			return CategoryNumber
+		}
+		return CategoryIdentifier
+	case ItemVariable:
+		return CategoryIdentifier
+	}
+	if k >= ItemKWAll {
+		return CategoryKeyword
+	}
+	return CategoryOperator
+}
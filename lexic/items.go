@@ -1,456 +1,330 @@
 package lexic
 
-import (
-	"fmt"
-	"os"
+import "fmt"
+
+// Kind identifies the kind of a Token
+type Kind int
+
+// Kinds of tokens
+const (
+	ItemError         Kind = iota // lexical error, its value holds the message
+	ItemEOF                       // end of the input
+	ItemComment                   // comment, // or /* */
+	ItemInvalid                   // text skipped after a lexical error, only returned by TokenizeTrivia
+	ItemIdentifier                // identifier
+	ItemString                    // text string, without its quotes
+	ItemRegex                     // regular expression, with its flags
+	ItemIntNumber                 // integer number
+	ItemFloatNumber               // float number
+	ItemVariable                  // string identifier such as $a, #a, @a or !a
+	ItemColon                     // :
+	ItemEqual                     // =
+	ItemOCurly                    // {
+	ItemCCurly                    // }
+	ItemOSqrt                     // [
+	ItemCSqrt                     // ]
+	ItemOBracket                  // (
+	ItemCBracket                  // )
+	ItemPipe                      // |
+	ItemSpace                     // blanks, only returned by TokenizeTrivia
+	ItemQMark                     // ?
+	ItemDash                      // -
+	ItemPlus                      // +
+	ItemHash                      // #
+	ItemDot                       // .
+	ItemDotDot                    // ..
+	ItemPercent                   // %
+	ItemBackSlash                 // \
+	ItemAnd                       // &
+	ItemLeftShift                 // <<
+	ItemRightShift                // >>
+	ItemBitNot                    // ~
+	ItemCaret                     // ^
+	ItemStar                      // *
+	ItemSlash                     // /
+	ItemComma                     // ,
+	ItemGrater                    // >
+	ItemLess                      // <
+	ItemGraterEqual               // >=
+	ItemLessEqual                 // <=
+	ItemEqualEqual                // ==
+	ItemNotEqual                  // !=
+	ItemNot                       // !
+	ItemAt                        // @
+	ItemKWAll                     // all
+	ItemKWAnd                     // and
+	ItemKWAny                     // any
+	ItemKWAscii                   // ascii
+	ItemKWAt                      // at
+	ItemKWBase64                  // base64
+	ItemKWBase64wide              // base64wide
+	ItemKWCondition               // condition
+	ItemKWContains                // contains
+	ItemKWDefined                 // defined
+	ItemKWEntrypoint              // entrypoint
+	ItemKWEndswith                // endswith
+	ItemKWFalse                   // false
+	ItemKWFilesize                // filesize
+	ItemKWFullword                // fullword
+	ItemKWFor                     // for
+	ItemKWGlobal                  // global
+	ItemKWIcontains               // icontains
+	ItemKWIendswith               // iendswith
+	ItemKWIequals                 // iequals
+	ItemKWIn                      // in
+	ItemKWImport                  // import
+	ItemKWInclude                 // include
+	ItemKWInt8                    // int8
+	ItemKWInt16                   // int16
+	ItemKWInt32                   // int32
+	ItemKWInt8be                  // int8be
+	ItemKWInt16be                 // int16be
+	ItemKWInt32be                 // int32be
+	ItemKWIstartswith             // istartswith
+	ItemKWMatches                 // matches
+	ItemKWMeta                    // meta
+	ItemKWNocase                  // nocase
+	ItemKWNone                    // none
+	ItemKWNot                     // not
+	ItemKWOr                      // or
+	ItemKWOf                      // of
+	ItemKWPrivate                 // private
+	ItemKWRule                    // rule
+	ItemKWStartswith              // startswith
+	ItemKWStrings                 // strings
+	ItemKWThem                    // them
+	ItemKWTrue                    // true
+	ItemKWUint8                   // uint8
+	ItemKWUint16                  // uint16
+	ItemKWUint32                  // uint32
+	ItemKWUint8be                 // uint8be
+	ItemKWUint16be                // uint16be
+	ItemKWUint32be                // uint32be
+	ItemKWWide                    // wide
+	ItemKWXor                     // xor
 )
 
-// Item interface for all Item in YaGo
-type Item interface {
-	String()
-	GetValue() string
-	GetType() string
-	GetLine() int
-	GetPos() int
-}
-
-type pos int
-
-// ItemYaGo basic information for each item
-type ItemYaGo struct {
-	Value string
-	Typ   string
-	Pos   pos
-	Line  int
-}
-
-// String prints on stdout the item value
-func (it ItemYaGo) String() {
-	os.Stdout.WriteString(fmt.Sprintf("%s", it.Value))
-}
-
-// GetValue return the item value
-func (it ItemYaGo) GetValue() string {
-	return string(it.Value)
-}
-
-// GetType returns the item type
-func (it ItemYaGo) GetType() string {
-	return string(it.Typ)
-}
-
-// GetLine returns where the item was found
-func (it ItemYaGo) GetLine() int {
-	return it.Line
-}
-
-// GetPos returns the item position inside the input
-func (it ItemYaGo) GetPos() int {
-	return int(it.Pos)
-}
-
-// ItemComment represents a comment Item
-type ItemComment struct {
-	ItemYaGo
-}
-
-// ItemEOF represents a EOF Item
-type ItemEOF struct {
-	ItemYaGo
-}
-
-// ItemIdentifier represents an identifier Item
-type ItemIdentifier struct {
-	ItemYaGo
-}
-
-// ItemString represents a string Item
-type ItemString struct {
-	ItemYaGo
-}
-
-// ItemRegex represents a identifier Item
-type ItemRegex struct {
-	ItemYaGo
-}
-
-// ItemIntNumber represents an integer number Item
-type ItemIntNumber struct {
-	ItemYaGo
-	Value int
-}
-
-// String prints on stdout the item value
-func (it ItemIntNumber) String() {
-	os.Stdout.WriteString(fmt.Sprintf("%d", it.Value))
-}
-
-// ItemVariable represents a variable Item
-type ItemVariable struct {
-	ItemYaGo
-}
-
-// ItemColon represents a colon Item
-type ItemColon struct {
-	ItemYaGo
-}
-
-// ItemEqual represents a equal Item
-type ItemEqual struct {
-	ItemYaGo
-}
-
-// ItemOCurly represents an open curly bracket Item
-type ItemOCurly struct {
-	ItemYaGo
-}
-
-// ItemCCurly represents a close curly bracket Item
-type ItemCCurly struct {
-	ItemYaGo
-}
-
-// ItemOSqrt represents an open square bracket Item
-type ItemOSqrt struct {
-	ItemYaGo
-}
-
-// ItemCSqrt represents a close square bracket Item
-type ItemCSqrt struct {
-	ItemYaGo
-}
-
-// ItemOBracket represents an open bracket Item
-type ItemOBracket struct {
-	ItemYaGo
-}
-
-// ItemCBracket represents a close bracket Item
-type ItemCBracket struct {
-	ItemYaGo
-}
-
-// ItemPipe represents a pipe Item
-type ItemPipe struct {
-	ItemYaGo
-}
-
-// ItemSpace represents a space Item
-type ItemSpace struct {
-	ItemYaGo
-}
-
-// ItemQMark represents a question mark Item
-type ItemQMark struct {
-	ItemYaGo
-}
-
-// ItemDash represents a dash Item
-type ItemDash struct {
-	ItemYaGo
-}
-
-// ItemPlus represents a plus Item
-type ItemPlus struct {
-	ItemYaGo
-}
-
-// ItemHash represents a hash Item
-type ItemHash struct {
-	ItemYaGo
-}
-
-// ItemDot represents a dot Item
-type ItemDot struct {
-	ItemYaGo
-}
-
-// ItemDotDot represents a dotdot Item
-type ItemDotDot struct {
-	ItemYaGo
-}
-
-// ItemCaret represents a caret Item
-type ItemCaret struct {
-	ItemYaGo
-}
-
-// ItemStar represents a star Item
-type ItemStar struct {
-	ItemYaGo
-}
-
-// ItemSlash represents a slash Item
-type ItemSlash struct {
-	ItemYaGo
-}
-
-// ItemComma represents a comma Item
-type ItemComma struct {
-	ItemYaGo
-}
-
-// ItemGrater represents a grater than Item
-type ItemGrater struct {
-	ItemYaGo
-}
-
-// ItemLess represents a less than Item
-type ItemLess struct {
-	ItemYaGo
-}
-
-// ItemGraterEqual represents a grater equal Item
-type ItemGraterEqual struct {
-	ItemYaGo
-}
-
-// ItemLessEqual represents a less equal Item
-type ItemLessEqual struct {
-	ItemYaGo
-}
-
-// ItemEqualEqual represents a equal equal Item
-type ItemEqualEqual struct {
-	ItemYaGo
-}
-
-// ItemNotl represents a not Item
-type ItemNot struct {
-	ItemYaGo
-}
-
-// ItemNotlEqual represents a not equal Item
-type ItemNotEqual struct {
-	ItemYaGo
-}
-
-// ItemPercent represents a percentage Item
-type ItemPercent struct {
-	ItemYaGo
-}
-
-// ItemAnd represents a and Item
-type ItemAnd struct {
-	ItemYaGo
-}
-
-// ItemLeftShift represents a left shift Item
-type ItemLeftShift struct {
-	ItemYaGo
-}
-
-// ItemRightShift right shift
-type ItemRightShift struct {
-	ItemYaGo
-}
-
-// ItemBitNot represents a bitwise not Item
-type ItemBitNot struct {
-	ItemYaGo
-}
-
-// ItemAt represents an at (@) Item
-type ItemAt struct {
-	ItemYaGo
-}
-
-// ItemKWAll represents an all keyword Item
-type ItemKWAll struct {
-	ItemYaGo
-}
-
-// ItemKWAnd represents an and keyword Item
-type ItemKWAnd struct {
-	ItemYaGo
-}
-
-// ItemKWAny represents an any keyword Item
-type ItemKWAny struct {
-	ItemYaGo
-}
-
-// ItemKWAscii represents an ascii keyword Item
-type ItemKWAscii struct {
-	ItemYaGo
-}
-
-// ItemKWAt represents an at keyword Item
-type ItemKWAt struct {
-	ItemYaGo
-}
-
-// ItemKWCondition represents a condition keyword Item
-type ItemKWCondition struct {
-	ItemYaGo
-}
-
-// ItemKWContains represents a contains keyword Item
-type ItemKWContains struct {
-	ItemYaGo
-}
-
-// ItemKWEntrypoint represents a entrypoint keyword Item
-type ItemKWEntrypoint struct {
-	ItemYaGo
-}
-
-// ItemKWFalse represents a false keyword Item
-type ItemKWFalse struct {
-	ItemYaGo
-}
-
-// ItemKWFilesize represents a filesize keyword Item
-type ItemKWFilesize struct {
-	ItemYaGo
-}
-
-// ItemKWFullword represents a fullword keyword Item
-type ItemKWFullword struct {
-	ItemYaGo
-}
-
-// ItemKWFor represents a for keyword Item
-type ItemKWFor struct {
-	ItemYaGo
-}
-
-// ItemKWGlobal represents a global keyword Item
-type ItemKWGlobal struct {
-	ItemYaGo
-}
-
-// ItemKWIn represents an in keyword Item
-type ItemKWIn struct {
-	ItemYaGo
-}
-
-// ItemKWImport represents an import keyword Item
-type ItemKWImport struct {
-	ItemYaGo
-}
-
-// ItemKWInclude represents an include keyword Item
-type ItemKWInclude struct {
-	ItemYaGo
-}
-
-// ItemKWInt8 represents an int8 keyword Item
-type ItemKWInt8 struct {
-	ItemYaGo
-}
-
-// ItemKWInt16 represents an int16 keyword Item
-type ItemKWInt16 struct {
-	ItemYaGo
-}
-
-// ItemKWInt32 represents an int32 keyword Item
-type ItemKWInt32 struct {
-	ItemYaGo
-}
-
-// ItemKWInt8be represents an int8be keyword Item
-type ItemKWInt8be struct {
-	ItemYaGo
-}
-
-// ItemKWInt16be represents an int16be keyword Item
-type ItemKWInt16be struct {
-	ItemYaGo
-}
-
-// ItemKWInt32be represents an int32be keyword Item
-type ItemKWInt32be struct {
-	ItemYaGo
-}
-
-// ItemKWMatches represents a matches keyword Item
-type ItemKWMatches struct {
-	ItemYaGo
-}
-
-// ItemKWMeta represents a meta keyword Item
-type ItemKWMeta struct {
-	ItemYaGo
-}
-
-// ItemKWNocase represents a nocase keyword Item
-type ItemKWNocase struct {
-	ItemYaGo
-}
-
-// ItemKWNot represents a not keyword Item
-type ItemKWNot struct {
-	ItemYaGo
-}
-
-// ItemKWOr represents an or keyword Item
-type ItemKWOr struct {
-	ItemYaGo
-}
-
-// ItemKWOf represents an of keyword Item
-type ItemKWOf struct {
-	ItemYaGo
-}
-
-// ItemKWPrivate represents a private keyword Item
-type ItemKWPrivate struct {
-	ItemYaGo
-}
-
-// ItemKWRule represents a rule keyword Item
-type ItemKWRule struct {
-	ItemYaGo
-}
-
-// ItemKWStrings represents a strings keyword Item
-type ItemKWStrings struct {
-	ItemYaGo
-}
-
-// ItemKWThem represents a them keyword Item
-type ItemKWThem struct {
-	ItemYaGo
-}
-
-// ItemKWTrue represents a true keyword Item
-type ItemKWTrue struct {
-	ItemYaGo
-}
-
-// ItemKWUint8 represents an uint8 keyword Item
-type ItemKWUint8 struct {
-	ItemYaGo
-}
-
-// ItemKWUint16 represents an uint16 keyword Item
-type ItemKWUint16 struct {
-	ItemYaGo
-}
-
-// ItemKWUint32 represents an uint32 keyword Item
-type ItemKWUint32 struct {
-	ItemYaGo
-}
-
-// ItemKWUint8be represents an uint8be keyword Item
-type ItemKWUint8be struct {
-	ItemYaGo
-}
-
-// ItemKWUint16be represents an uint16be keyword Item
-type ItemKWUint16be struct {
-	ItemYaGo
-}
-
-// ItemKWUint32be represents an uint32be keyword Item
-type ItemKWUint32be struct {
-	ItemYaGo
-}
-
-// ItemKWWide represents a wide keyword Item
-type ItemKWWide struct {
-	ItemYaGo
+// kindNames holds the names of the kinds, as used in error messages
+var kindNames = [...]string{
+	ItemError:         "__ERROR__",
+	ItemEOF:           "__EOF__",
+	ItemComment:       "__COMMENT__",
+	ItemInvalid:       "__INVALID__",
+	ItemIdentifier:    "__IDENTIFIER__",
+	ItemString:        "__STRING__",
+	ItemRegex:         "__REGEX__",
+	ItemIntNumber:     "__INT_NUMBER__",
+	ItemFloatNumber:   "__FLOAT_NUMBER__",
+	ItemVariable:      "__VARIABLE__",
+	ItemColon:         "__COLON__",
+	ItemEqual:         "__EQUAL__",
+	ItemOCurly:        "__OPEN_CURLY__",
+	ItemCCurly:        "__CLOSE_CURLY__",
+	ItemOSqrt:         "__OPEN_SQRT__",
+	ItemCSqrt:         "__CLOSE_SQRT__",
+	ItemOBracket:      "__OPEN_BRACKET__",
+	ItemCBracket:      "__CLOSE_BRACKET__",
+	ItemPipe:          "__PIPE__",
+	ItemSpace:         "__SPACE__",
+	ItemQMark:         "__QMAKR__",
+	ItemDash:          "__DASH__",
+	ItemPlus:          "__PLUS__",
+	ItemHash:          "__HASH__",
+	ItemDot:           "__DOT__",
+	ItemDotDot:        "__DOT_DOT__",
+	ItemPercent:       "__PERCENT__",
+	ItemBackSlash:     "__BACK_SLASH__",
+	ItemAnd:           "__BIT_AND__",
+	ItemLeftShift:     "__LEFT_SHIFT__",
+	ItemRightShift:    "__RIGTH_SHIFT__",
+	ItemBitNot:        "__BIT_NOT__",
+	ItemCaret:         "__CARET__",
+	ItemStar:          "__STAR__",
+	ItemSlash:         "__SLASH__",
+	ItemComma:         "__COMMA__",
+	ItemGrater:        "__GRATER__",
+	ItemLess:          "__LESS__",
+	ItemGraterEqual:   "__GRATER_EQUAL__",
+	ItemLessEqual:     "__LESS_EQUAL__",
+	ItemEqualEqual:    "__EQUAL_EQUAL__",
+	ItemNotEqual:      "__NOT_EQUAL__",
+	ItemNot:           "__NOT__",
+	ItemAt:            "__AT__",
+	ItemKWAll:         "__KW_ALL__",
+	ItemKWAnd:         "__KW_AND__",
+	ItemKWAny:         "__KW_ANY__",
+	ItemKWAscii:       "__KW_ASCII__",
+	ItemKWAt:          "__KW_AT__",
+	ItemKWBase64:      "__KW_BASE64__",
+	ItemKWBase64wide:  "__KW_BASE64WIDE__",
+	ItemKWCondition:   "__KW_CONDITION__",
+	ItemKWContains:    "__KW_CONTAINS__",
+	ItemKWDefined:     "__KW_DEFINED__",
+	ItemKWEntrypoint:  "__KW_ENTRYPOINT__",
+	ItemKWEndswith:    "__KW_ENDSWITH__",
+	ItemKWFalse:       "__KW_FLASE__",
+	ItemKWFilesize:    "__KW_FILESIZE__",
+	ItemKWFullword:    "__KW_FULLWORD__",
+	ItemKWFor:         "__KW_FOR__",
+	ItemKWGlobal:      "__KW_GLOBAL__",
+	ItemKWIcontains:   "__KW_ICONTAINS__",
+	ItemKWIendswith:   "__KW_IENDSWITH__",
+	ItemKWIequals:     "__KW_IEQUALS__",
+	ItemKWIn:          "__KW_IN__",
+	ItemKWImport:      "__KW_IMPORT__",
+	ItemKWInclude:     "__KW_INCLUDE__",
+	ItemKWInt8:        "__KW_INT8__",
+	ItemKWInt16:       "__KW_INT16__",
+	ItemKWInt32:       "__KW_INT32__",
+	ItemKWInt8be:      "__KW_INT8BE__",
+	ItemKWInt16be:     "__KW_INT16BE__",
+	ItemKWInt32be:     "__KW_INT32BE__",
+	ItemKWIstartswith: "__KW_ISTARTSWITH__",
+	ItemKWMatches:     "__KW_MATCHES__",
+	ItemKWMeta:        "__KW_META__",
+	ItemKWNocase:      "__KW_NOCASE__",
+	ItemKWNone:        "__KW_NONE__",
+	ItemKWNot:         "__KW_NOT__",
+	ItemKWOr:          "__KW_OR__",
+	ItemKWOf:          "__KW_OF__",
+	ItemKWPrivate:     "__KW_PRIVATE__",
+	ItemKWRule:        "__KW_RULE__",
+	ItemKWStartswith:  "__KW_STARTSWITH__",
+	ItemKWStrings:     "__KW_STRINGS__",
+	ItemKWThem:        "__KW_THEM__",
+	ItemKWTrue:        "__KW_TRUE__",
+	ItemKWUint8:       "__KW_UINT8__",
+	ItemKWUint16:      "__KW_UINT16__",
+	ItemKWUint32:      "__KW_UINT32__",
+	ItemKWUint8be:     "__KW_UINT8BE__",
+	ItemKWUint16be:    "__KW_UINT16BE__",
+	ItemKWUint32be:    "__KW_UINT32BE__",
+	ItemKWWide:        "__KW_WIDE__",
+	ItemKWXor:         "__KW_XOR__",
+}
+
+// String returns the name of k, such as __KW_RULE__ or __COLON__
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// keywords maps the Yara keywords to their kind
+var keywords = map[string]Kind{
+	"all":         ItemKWAll,
+	"and":         ItemKWAnd,
+	"any":         ItemKWAny,
+	"ascii":       ItemKWAscii,
+	"at":          ItemKWAt,
+	"base64":      ItemKWBase64,
+	"base64wide":  ItemKWBase64wide,
+	"condition":   ItemKWCondition,
+	"contains":    ItemKWContains,
+	"defined":     ItemKWDefined,
+	"entrypoint":  ItemKWEntrypoint,
+	"endswith":    ItemKWEndswith,
+	"false":       ItemKWFalse,
+	"filesize":    ItemKWFilesize,
+	"fullword":    ItemKWFullword,
+	"for":         ItemKWFor,
+	"global":      ItemKWGlobal,
+	"icontains":   ItemKWIcontains,
+	"iendswith":   ItemKWIendswith,
+	"iequals":     ItemKWIequals,
+	"in":          ItemKWIn,
+	"import":      ItemKWImport,
+	"include":     ItemKWInclude,
+	"int8":        ItemKWInt8,
+	"int16":       ItemKWInt16,
+	"int32":       ItemKWInt32,
+	"int8be":      ItemKWInt8be,
+	"int16be":     ItemKWInt16be,
+	"int32be":     ItemKWInt32be,
+	"istartswith": ItemKWIstartswith,
+	"matches":     ItemKWMatches,
+	"meta":        ItemKWMeta,
+	"nocase":      ItemKWNocase,
+	"none":        ItemKWNone,
+	"not":         ItemKWNot,
+	"or":          ItemKWOr,
+	"of":          ItemKWOf,
+	"private":     ItemKWPrivate,
+	"rule":        ItemKWRule,
+	"startswith":  ItemKWStartswith,
+	"strings":     ItemKWStrings,
+	"them":        ItemKWThem,
+	"true":        ItemKWTrue,
+	"uint8":       ItemKWUint8,
+	"uint16":      ItemKWUint16,
+	"uint32":      ItemKWUint32,
+	"uint8be":     ItemKWUint8be,
+	"uint16be":    ItemKWUint16be,
+	"uint32be":    ItemKWUint32be,
+	"wide":        ItemKWWide,
+	"xor":         ItemKWXor,
+}
+
+// Token is an item of the input: its kind, its text and where it starts.
+// The Value of strings does not include the quotes, their Raw text does.
+type Token struct {
+	Kind          Kind
+	Category      Category
+	Value         string
+	Raw           string // source text of the token, empty for errors and EOF
+	Pos           int    // offset of Value from the beginning of the input, in bytes
+	Line          int    // line where Raw starts
+	Column        int    // column where Raw starts, in bytes
+	RuneColumn    int    // column where Raw starts, in runes
+	EndLine       int    // line where Raw ends
+	EndColumn     int    // column right after Raw, in bytes
+	EndRuneColumn int    // column right after Raw, in runes
+
+	Decoded string  // value of strings, escape sequences decoded
+	Int     int64   // value of integer numbers
+	Float   float64 // value of float numbers
+}
+
+// Item is the former name of Token
+type Item = Token
+
+// String returns the text of the token
+func (t Token) String() string {
+	return t.Value
+}
+
+// Span returns the offsets where the Raw text of the token starts and ends
+// in the input
+func (t Token) Span() (int, int) {
+	start := t.Pos
+	if t.Kind == ItemString {
+		start-- // opening quote
+	}
+	return start, start + len(t.Raw)
+}
+
+// GetValue returns the text of the token
+func (t Token) GetValue() string {
+	return t.Value
+}
+
+// GetType returns the name of the kind of the token
+func (t Token) GetType() string {
+	return t.Kind.String()
+}
+
+// GetLine returns where the token was found
+func (t Token) GetLine() int {
+	return t.Line
+}
+
+// GetPos returns the token position inside the input
+func (t Token) GetPos() int {
+	return t.Pos
+}
+
+// GetColumn returns the column where the token starts
+func (t Token) GetColumn() int {
+	return t.Column
 }
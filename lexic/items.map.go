@@ -1,85 +0,0 @@
-package lexic
-
-// ItemType represents the type of items
-var ItemType = map[string]string{
-	"ItemComment":      "__COMMENT__",
-	"ItemEOF":          "__EOF__",
-	"ItemIdentifier":   "__IDENTIFIER__",
-	"ItemString":       "__STRING__",
-	"ItemRegex":        "__REGEX__",
-	"ItemIntNumber":    "__INT_NUMBER__",
-	"ItemVariable":     "__VARIABLE__",
-	"ItemColon":        "__COLON__",
-	"ItemEqual":        "__EQUAL__",
-	"ItemOCurly":       "__OPEN_CURLY__",
-	"ItemCCurly":       "__CLOSE_CURLY__",
-	"ItemOSqrt":        "__OPEN_SQRT__",
-	"ItemCSqrt":        "__CLOSE_SQRT__",
-	"ItemOBracket":     "__OPEN_BRACKET__",
-	"ItemCBracket":     "__CLOSE_BRACKET__",
-	"ItemPipe":         "__PIPE__",
-	"ItemSpace":        "__SPACE__",
-	"ItemQMark":        "__QMAKR__",
-	"ItemDash":         "__DASH__",
-	"ItemPlus":         "__PLUS__",
-	"ItemHash":         "__HASH__",
-	"ItemDot":          "__DOT__",
-	"ItemDotDot":       "__DOT_DOT__",
-	"ItemPercent":      "__PERCENT__",
-	"ItemAnd":          "__BIT_AND__",
-	"ItemLeftShift":    "__LEFT_SHIFT__",
-	"ItemRightShift":   "__RIGTH_SHIFT__",
-	"ItemBitNot":       "__BIT_NOT__",
-	"ItemCaret":        "__CARET__",
-	"ItemStar":         "__STAR__",
-	"ItemSlash":        "__SLASH__",
-	"ItemComma":        "__COMMA__",
-	"ItemGrater":       "__GRATER__",
-	"ItemLess":         "__LESS__",
-	"ItemGraterEqual":  "__GRATER_EQUAL__",
-	"ItemLessEqual":    "__LESS_EQUAL__",
-	"ItemEqualEqual":   "__EQUAL_EQUAL__",
-	"ItemNotEqual":     "__NOT_EQUAL__",
-	"ItemNot":          "__NOT__",
-	"ItemAt":           "__AT__",
-	"ItemKWAll":        "__KW_ALL__",
-	"ItemKWAnd":        "__KW_AND__",
-	"ItemKWAny":        "__KW_ANY__",
-	"ItemKWAscii":      "__KW_ASCII__",
-	"ItemKWAt":         "__KW_AT__",
-	"ItemKWCondition":  "__KW_CONDITION__",
-	"ItemKWContains":   "__KW_CONTAINS__",
-	"ItemKWEntrypoint": "__KW_ENTRYPOINT__",
-	"ItemKWFalse":      "__KW_FLASE__",
-	"ItemKWFilesize":   "__KW_FILESIZE__",
-	"ItemKWFullword":   "__KW_FULLWORD__",
-	"ItemKWFor":        "__KW_FOR__",
-	"ItemKWGlobal":     "__KW_GLOBAL__",
-	"ItemKWIn":         "__KW_IN__",
-	"ItemKWImport":     "__KW_IMPORT__",
-	"ItemKWInclude":    "__KW_INCLUDE__",
-	"ItemKWInt8":       "__KW_INT8__",
-	"ItemKWInt16":      "__KW_INT16__",
-	"ItemKWInt32":      "__KW_INT32__",
-	"ItemKWInt8be":     "__KW_INT8BE__",
-	"ItemKWInt16be":    "__KW_INT16BE__",
-	"ItemKWInt32be":    "__KW_INT32BE__",
-	"ItemKWMatches":    "__KW_MATCHES__",
-	"ItemKWMeta":       "__KW_META__",
-	"ItemKWNocase":     "__KW_NOCASE__",
-	"ItemKWNot":        "__KW_NOT__",
-	"ItemKWOr":         "__KW_OR__",
-	"ItemKWOf":         "__KW_OF__",
-	"ItemKWPrivate":    "__KW_PRIVATE__",
-	"ItemKWRule":       "__KW_RULE__",
-	"ItemKWStrings":    "__KW_STRINGS__",
-	"ItemKWThem":       "__KW_THEM__",
-	"ItemKWTrue":       "__KW_TRUE__",
-	"ItemKWUint8":      "__KW_UINT8__",
-	"ItemKWUint16":     "__KW_UINT16__",
-	"ItemKWUint32":     "__KW_UINT32__",
-	"ItemKWUint8be":    "__KW_UINT8BE__",
-	"ItemKWUint16be":   "__KW_UINT16BE__",
-	"ItemKWUint32be":   "__KW_UINT32BE__",
-	"ItemKWWide":       "__KW_WIDE__",
-}
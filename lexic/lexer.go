@@ -1,63 +1,203 @@
 package lexic
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"unicode/utf8"
 )
 
 type stateFn func(*Lexer) stateFn
 
+// pos is an offset in the input, in bytes
+type pos int
+
 // EOF represents a End Of File
 const EOF = -1
 
-// Lexer holds the state of the scanner.
+// chunkSize is how much input is read at once when lexing a reader
+const chunkSize = 64 * 1024
+
+// Lexer holds the state of the scanner. Items are scanned on demand by
+// Next. Lexers created with Lex or LexReader scan in a goroutine of their
+// own and send their Items on the Items channel instead.
 type Lexer struct {
 	Name    string    // the name of the input; used only for error reports
-	Input   string    // the string being scanned
+	Input   string    // the string being scanned, from Base on
+	Base    pos       // position of the first byte of Input
 	State   stateFn   // the next lexing function to enter
 	Pos     pos       // current position in the input
 	Start   pos       // start position of this Item
 	Width   pos       // width of last rune read from input
 	LastPos pos       // position of most recent Item returned by nextItem
-	Items   chan Item // channel of scanned Items
+	Items   chan Item // channel of scanned Items, nil unless created with Lex or LexReader
 	Line    int       // 1+number of newlines seen
+	Column  int       // column of Start, in bytes
+	line    int       // line of Start
+	runes   int       // column of Start, in runes
+
+	pending       []Item    // Items scanned but not returned by Next yet
+	lineStart     pos       // where the line of Pos starts
+	prevLineStart pos       // where the line before it starts, for backup
+	posRunes      int       // column of Pos, in runes
+	prevPosRunes  int       // column of the end of the line before, for backup
+	reader        io.Reader // where more input is read from, nil once exhausted
+	readErr       error     // error found reading, other than io.EOF
+	last          Kind      // kind of the last Item emitted, comments aside
+	inHex         bool      // scanning a hex string
 }
 
-// Lex creates a new scanner for the input string.
-func Lex(name, input string) *Lexer {
-	l := &Lexer{
-		Name:  name,
-		Input: input,
-		Items: make(chan Item),
-		Line:  1,
+// Error is a lexical error, returned by Next along with the Error Item
+type Error struct {
+	Name       string
+	Msg        string
+	Pos        int
+	Line       int
+	Column     int // in bytes
+	RuneColumn int
+}
+
+// Error returns the error formatted as name:line:column: message
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Name, e.Line, e.Column, e.Msg)
+}
+
+// New creates a new scanner for the input string.
+func New(name, input string) *Lexer {
+	return &Lexer{
+		Name:   name,
+		Input:  input,
+		State:  lexText,
+		Line:   1,
+		Column: 1,
+		line:   1,
+		runes:  1,
+
+		posRunes: 1,
 	}
+}
+
+// NewReader creates a new scanner reading its input from r as it goes.
+// Only the input of the Item being scanned is kept, so the memory used does
+// not depend on the size of the input.
+func NewReader(name string, r io.Reader) *Lexer {
+	l := New(name, "")
+	l.reader = r
+	return l
+}
+
+// Lex creates a new scanner for the input string, sending its Items on the
+// Items channel from a goroutine. It is kept for compatibility, New does
+// not need the goroutine.
+func Lex(name, input string) *Lexer {
+	l := New(name, input)
+	l.Items = make(chan Item)
+	go l.run()
+	return l
+}
+
+// LexReader is NewReader sending its Items on the Items channel from a
+// goroutine, like Lex.
+func LexReader(name string, r io.Reader) *Lexer {
+	l := NewReader(name, r)
+	l.Items = make(chan Item)
 	go l.run()
 	return l
 }
 
-// run runs the state machine for the lexer.
+// Tokenize returns the Items of input, without the final EOF Item. It stops
+// at the first lexical error, returning the Items before it.
+func Tokenize(input string) ([]Item, error) {
+	l := New("", input)
+	var items []Item
+	for {
+		item, err := l.Next()
+		if err != nil {
+			return items, err
+		}
+		if item.Kind == ItemEOF {
+			return items, nil
+		}
+		items = append(items, item)
+	}
+}
+
+// Err returns the error found reading the input of NewReader, if any. The
+// input ends there as if it were the end of the file.
+func (l *Lexer) Err() error {
+	return l.readErr
+}
+
+// fill drops the input before the Item being scanned and reads more
+func (l *Lexer) fill() {
+	buf := make([]byte, chunkSize)
+	n, err := l.reader.Read(buf)
+	for n == 0 && err == nil {
+		n, err = l.reader.Read(buf)
+	}
+	l.Input = l.Input[l.Start-l.Base:] + string(buf[:n])
+	l.Base = l.Start
+	if err != nil {
+		l.reader = nil
+		if err != io.EOF {
+			l.readErr = err
+		}
+	}
+}
+
+// run sends the Items on the Items channel, up to the EOF Item.
 func (l *Lexer) run() {
-	for l.State = lexText; l.State != nil; {
-		l.State = l.State(l)
+	for {
+		item, _ := l.Next()
+		l.Items <- item
+		if item.Kind == ItemEOF {
+			break
+		}
 	}
 	close(l.Items)
 }
 
+// Next scans and returns the next Item. Once the input is exhausted it keeps
+// returning an EOF Item, along with the error found reading the input of
+// NewReader if any. Lexical errors are returned as an Error Item and an
+// *Error, after which scanning goes on.
+func (l *Lexer) Next() (Item, error) {
+	for len(l.pending) == 0 {
+		if l.State == nil {
+			return l.here(ItemEOF, ""), l.readErr
+		}
+		l.State = l.State(l)
+	}
+	item := l.pending[0]
+	l.pending = l.pending[:copy(l.pending, l.pending[1:])]
+	switch item.Kind {
+	case ItemError:
+		return item, &Error{Name: l.Name, Msg: item.Value, Pos: item.Pos, Line: item.Line, Column: item.Column, RuneColumn: item.RuneColumn}
+	case ItemEOF:
+		return item, l.readErr
+	}
+	return item, nil
+}
+
 // next returns the next rune in the input.
 func (l *Lexer) next() rune {
-	if int(l.Pos) >= len(l.Input) {
+	if l.reader != nil && int(l.Pos-l.Base)+utf8.UTFMax > len(l.Input) {
+		l.fill()
+	}
+	if int(l.Pos-l.Base) >= len(l.Input) {
 		l.Width = 0
 		return EOF
 	}
-	r, w := utf8.DecodeRuneInString(l.Input[l.Pos:])
+	r, w := utf8.DecodeRuneInString(l.Input[l.Pos-l.Base:])
 
 	l.Width = pos(w)
 	l.Pos += l.Width
 	if r == '\n' {
 		l.Line++
+		l.prevLineStart, l.lineStart = l.lineStart, l.Pos
+		l.prevPosRunes, l.posRunes = l.posRunes, 1
+	} else {
+		l.posRunes++
 	}
 	return r
 }
@@ -73,184 +213,115 @@ func (l *Lexer) peek() rune {
 func (l *Lexer) backup() {
 	l.Pos -= l.Width
 	// Correct newline count.
-	if l.Width == 1 && l.Input[l.Pos] == '\n' {
+	if l.Width == 1 && l.Input[l.Pos-l.Base] == '\n' {
 		l.Line--
+		l.lineStart = l.prevLineStart
+		l.posRunes = l.prevPosRunes
+	} else if l.Width > 0 {
+		l.posRunes--
 	}
 }
 
 // emit passes an Item back to the client.
-func (l *Lexer) emit(itemName string) {
-	var it Item
-
-	switch itemName {
-	case "ItemComment":
-		it = NewItemComment(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemEOF":
-		it = NewItemEOF(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemIdentifier":
-		it = NewItemIdentifier(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemString":
-		it = NewItemString(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemRegex":
-		it = NewItemRegex(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemIntNumber":
-		it = NewItemIntNumber(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemVariable":
-		it = NewItemVariable(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemColon":
-		it = NewItemColon(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemEqual":
-		it = NewItemEqual(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemOCurly":
-		it = NewItemOCurly(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemCCurly":
-		it = NewItemCCurly(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemOSqrt":
-		it = NewItemOSqrt(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemCSqrt":
-		it = NewItemCSqrt(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemOBracket":
-		it = NewItemOBracket(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemCBracket":
-		it = NewItemCBracket(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemPipe":
-		it = NewItemPipe(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemSpace":
-		it = NewItemSpace(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemQMark":
-		it = NewItemQMark(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemDash":
-		it = NewItemDash(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemPlus":
-		it = NewItemPlus(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemHash":
-		it = NewItemHash(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemDot":
-		it = NewItemDot(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemDotDot":
-		it = NewItemDotDot(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemCaret":
-		it = NewItemCaret(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemStar":
-		it = NewItemStar(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemSlash":
-		it = NewItemSlash(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemComma":
-		it = NewItemComma(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemGrater":
-		it = NewItemGrater(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemLess":
-		it = NewItemLess(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemGraterEqual":
-		it = NewItemGraterEqual(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemLessEqual":
-		it = NewItemLessEqual(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemEqualEqual":
-		it = NewItemEqualEqual(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemNotEqual":
-		it = NewItemNotEqual(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemNot":
-		it = NewItemNot(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemAnd":
-		it = NewItemAnd(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemRightShift":
-		it = NewItemRightShift(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemLeftShift":
-		it = NewItemLeftShift(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemAt":
-		it = NewItemAt(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemPercent":
-		it = NewItemPercent(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWAll":
-		it = NewItemKWAll(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWAnd":
-		it = NewItemKWAnd(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWAny":
-		it = NewItemKWAny(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWAscii":
-		it = NewItemKWAscii(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWAt":
-		it = NewItemKWAt(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWCondition":
-		it = NewItemKWCondition(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWContains":
-		it = NewItemKWContains(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWEntrypoint":
-		it = NewItemKWEntrypoint(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWFalse":
-		it = NewItemKWFalse(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWFilesize":
-		it = NewItemKWFilesize(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWFullword":
-		it = NewItemKWFullword(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWFor":
-		it = NewItemKWFor(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWGlobal":
-		it = NewItemKWGlobal(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWIn":
-		it = NewItemKWIn(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWImport":
-		it = NewItemKWImport(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInclude":
-		it = NewItemKWInclude(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt8":
-		it = NewItemKWInt8(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt16":
-		it = NewItemKWInt16(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt32":
-		it = NewItemKWInt32(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt8be":
-		it = NewItemKWInt8be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt16be":
-		it = NewItemKWInt16be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWInt32be":
-		it = NewItemKWInt32be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWMatches":
-		it = NewItemKWMatches(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWMeta":
-		it = NewItemKWMeta(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWNocase":
-		it = NewItemKWNocase(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWNot":
-		it = NewItemKWNot(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWOr":
-		it = NewItemKWOr(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWOf":
-		it = NewItemKWOf(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWPrivate":
-		it = NewItemKWPrivate(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWRule":
-		it = NewItemKWRule(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWStrings":
-		it = NewItemKWStrings(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWThem":
-		it = NewItemKWThem(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWTrue":
-		it = NewItemKWTrue(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint8":
-		it = NewItemKWUint8(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint16":
-		it = NewItemKWUint16(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint32":
-		it = NewItemKWUint32(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint8be":
-		it = NewItemKWUint8be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint16be":
-		it = NewItemKWUint16be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWUint32be":
-		it = NewItemKWUint32be(l.Input[l.Start:l.Pos], l.Start, l.Line)
-	case "ItemKWWide":
-		it = NewItemKWWide(l.Input[l.Start:l.Pos], l.Start, l.Line)
-		// default:
-		// 	panic(fmt.Sprintf("ERROR: %s is not a valid Item.", itemName))
+func (l *Lexer) emit(k Kind) {
+	value := l.scanned()
+	raw := value
+	start, end := 0, 0 // bytes and runes of Raw before Start and after Pos
+	if k == ItemString {
+		raw = l.quoted()
+		start, end = 1, 1 // the quotes
 	}
-	l.Items <- it
-	l.Start = l.Pos
+	l.pending = append(l.pending, Token{
+		Kind:          k,
+		Category:      l.category(k, value),
+		Value:         value,
+		Raw:           raw,
+		Pos:           int(l.Start),
+		Line:          l.line,
+		Column:        l.Column - start,
+		RuneColumn:    l.runes - start,
+		EndLine:       l.Line,
+		EndColumn:     l.column() + end,
+		EndRuneColumn: l.posRunes + end,
+	})
+	l.setStart()
+}
+
+// quoted returns the string being scanned with its quotes
+func (l *Lexer) quoted() string {
+	start, end := l.Start-l.Base-1, l.Pos-l.Base+1
+	if start >= 0 && int(end) <= len(l.Input) {
+		return l.Input[start:end]
+	}
+	return `"` + l.scanned() + `"` // the quotes went out of the window
+}
+
+// category returns the category of an Item of kind k. Hex strings are the
+// items from an opening brace right after = to the closing brace.
+func (l *Lexer) category(k Kind, value string) Category {
+	if k == ItemComment {
+		return CategoryComment
+	}
+	last := l.last
+	l.last = k
+	switch {
+	case l.inHex:
+		l.inHex = k != ItemCCurly
+		return CategoryHex
+	case k == ItemOCurly && last == ItemEqual:
+		l.inHex = true
+		return CategoryHex
+	}
+	return kindCategory(k, value)
 }
 
 // ignore skips over the pending input before this point.
 func (l *Lexer) ignore() {
+	l.setStart()
+}
+
+// setStart starts a new Item at the current position
+func (l *Lexer) setStart() {
 	l.Start = l.Pos
+	l.Column = l.column()
+	l.line = l.Line
+	l.runes = l.posRunes
+}
+
+// lastItem returns the Item emitted last, to set its value
+func (l *Lexer) lastItem() *Token {
+	return &l.pending[len(l.pending)-1]
+}
+
+// peekByte returns the byte n bytes after the current position, or 0. The
+// bytes up to the next rune are available once peek has been called.
+func (l *Lexer) peekByte(n int) byte {
+	if i := int(l.Pos-l.Base) + n; i < len(l.Input) {
+		return l.Input[i]
+	}
+	return 0
+}
+
+// here returns an empty token of kind k at the current position
+func (l *Lexer) here(k Kind, value string) Token {
+	line, column := l.Line, l.column()
+	return Token{
+		Kind:          k,
+		Category:      kindCategory(k, value),
+		Value:         value,
+		Pos:           int(l.Pos),
+		Line:          line,
+		Column:        column,
+		RuneColumn:    l.posRunes,
+		EndLine:       line,
+		EndColumn:     column,
+		EndRuneColumn: l.posRunes,
+	}
+}
+
+// column returns the column of the current position, in bytes
+func (l *Lexer) column() int {
+	return int(l.Pos-l.lineStart) + 1
 }
 
 // accept consumes the next rune if it's from the valid set.
@@ -270,38 +341,49 @@ func (l *Lexer) acceptRun(valid string) {
 }
 
 func (l *Lexer) scanned() string {
-	return l.Input[l.Start:l.Pos]
+	return l.Input[l.Start-l.Base : l.Pos-l.Base]
 }
 
-// errorf returns an error token and terminates the scan by passing
-// back a nil pointer that will be the next state, terminating l.nextItem.
-func (l *Lexer) errorf(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	err := make(map[string]string)
-
-	err["lexical"] = "error"
-	err["line"] = fmt.Sprintf("%d", l.Line)
-	err["msg"] = msg
-
-	j, _ := json.Marshal(err)
-	os.Stderr.Write(j)
-	os.Exit(1)
+// errorf emits an error token and resumes scanning with lexText, so it is
+// up to the parser to either stop or skip the offending input.
+func (l *Lexer) errorf(format string, args ...interface{}) stateFn {
+	l.pending = append(l.pending, l.here(ItemError, fmt.Sprintf(format, args...)))
+	l.setStart()
+	return lexText
 }
 
-// NextItem returns the next Item from the input.
-// Called by the parser, not in the lexing goroutine.
+// NextItem returns the next Item from the input, read from the Items
+// channel when the lexer was created with Lex or LexReader.
+// Once the input is exhausted it keeps returning an EOF Item.
 func (l *Lexer) NextItem() Item {
-	item := <-l.Items
+	if l.Items == nil {
+		item, _ := l.Next()
+		return item
+	}
+	item, ok := <-l.Items
+	if !ok {
+		return l.here(ItemEOF, "")
+	}
 	return item
 }
 
+// Drain consumes the remaining Items so the lexing goroutine of Lex and
+// LexReader can finish. It does nothing for other lexers.
+func (l *Lexer) Drain() {
+	if l.Items == nil {
+		return
+	}
+	for range l.Items {
+	}
+}
+
 // lexText scans until an opening action delimiter, "{{".
 func lexText(l *Lexer) stateFn {
 	r := l.next()
 	for !isEOF(r) {
 		switch {
 		case isEOF(r):
-			l.emit("ItemEOF")
+			l.emit(ItemEOF)
 			return nil
 		case isBlank(r):
 			l.acceptRun(blankChars)
@@ -355,26 +437,25 @@ func lexText(l *Lexer) stateFn {
 			return scanGrater
 		case isLess(r):
 			return scanLess
-			r = l.next()
 		case isNot(r):
 			return scanNot
-			r = l.next()
 		case isAnd(r):
 			return scanAnd
-			r = l.next()
 		case isPercent(r):
 			return scanPercent
-			r = l.next()
 		case isBitNot(r):
 			return scanBitNot
-			r = l.next()
+		case isBackSlash(r):
+			return scanBackSlash
+		default:
+			l.backup() // Report the character where it is
+			l.errorf("Unexpected character %q", r)
+			l.next()
+			l.ignore()
+			return lexText
 		}
 		r = l.next()
 	}
-	if isEOF(r) {
-		l.emit("ItemEOF")
-	} else {
-		l.errorf("Not EOF Found")
-	}
+	l.emit(ItemEOF)
 	return nil
 }
@@ -0,0 +1,41 @@
+package lexic
+
+import "testing"
+
+func TestUnexpectedCharacter(t *testing.T) {
+	_, err := Tokenize("rule a { condition: true ; }")
+	lexErr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Tokenize: error %v, want a *Error", err)
+	}
+	if lexErr.Line != 1 || lexErr.Column != 26 {
+		t.Errorf("error at %d:%d, want 1:26", lexErr.Line, lexErr.Column)
+	}
+}
+
+func TestBackSlash(t *testing.T) {
+	items, err := Tokenize(`filesize \ 2`)
+	if err != nil {
+		t.Fatalf("Tokenize: %v", err)
+	}
+	if len(items) != 3 || items[1].Kind != ItemBackSlash {
+		t.Errorf("Tokenize: %v, want filesize, \\ and 2", items)
+	}
+}
+
+func TestStringColumns(t *testing.T) {
+	items, err := Tokenize("$a = \"é\\x41\"\r\n")
+	if err != nil {
+		t.Fatalf("Tokenize: %v", err)
+	}
+	str := items[2]
+	if str.Kind != ItemString || str.Raw != `"é\x41"` {
+		t.Fatalf("Tokenize: %v, want a string", items)
+	}
+	if str.Column != 6 || len(str.Raw) != str.EndColumn-str.Column {
+		t.Errorf("string at columns %d to %d, want 6 to %d", str.Column, str.EndColumn, 6+len(str.Raw))
+	}
+	if str.RuneColumn != 6 || str.EndRuneColumn != 13 {
+		t.Errorf("string at rune columns %d to %d, want 6 to 13", str.RuneColumn, str.EndRuneColumn)
+	}
+}
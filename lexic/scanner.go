@@ -1,19 +1,16 @@
 package lexic
 
 import (
-	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
-// kwList contains all keywords
-var kwList = []string{"all", "and", "any", "ascii", "at", "condition", "contains", "entrypoint", "false", "filesize", "fullword", "for", "global", "in", "import", "include", "int8", "int16", "int32", "int8be", "int16be", "int32be", "matches", "meta", "nocase", "not", "or", "of", "private", "rule", "strings", "them", "true", "uint8", "uint16", "uint32", "uint8be", "uint16be", "uint32be", "wide"}
-
 const (
 	// Keyword max length
 	maxKeywordLength = 11
 	// Characters to omit
-	blankChars = " \t\n"
+	blankChars = " \t\r\n"
 )
 
 func scanCommentOrRegex(l *Lexer) stateFn {
@@ -24,24 +21,26 @@ func scanCommentOrRegex(l *Lexer) stateFn {
 			r = l.next()
 			if r == '*' && l.peek() == '/' {
 				r = l.next()
-				l.emit("ItemComment")
+				l.emit(ItemComment)
 				return lexText
 			}
 			if r == EOF {
-				l.backup()
-				l.errorf("Line %d: Expecting end of comment and found end of file.", l.Line)
+				return l.errorf("Expecting end of comment and found end of file")
 			}
 		}
-	} else if l.peek() == '/' { // inline
-		r := l.next()
-		for !isEndOfLine(r) {
-			r = l.next()
+	} else if l.peek() == '/' { // inline, without the \r or \n ending it
+		for !isEndOfLine(l.peek()) && !isEOF(l.peek()) {
+			l.next()
 		}
-		l.emit("ItemComment")
+		l.emit(ItemComment)
 		return lexText
 	} else {
 		r := l.next()
 		for !isSlash(r) {
+			if isEOF(r) || isEndOfLine(r) {
+				l.backup()
+				return l.errorf("Expecting end of regular expression and found end of line")
+			}
 			if isBackSlash(r) {
 				if isBackSlash(l.peek()) {
 					r = l.next()
@@ -52,44 +51,35 @@ func scanCommentOrRegex(l *Lexer) stateFn {
 			r = l.next()
 		}
 
-		mod := ""
 		r = l.next()
-		if !isBlank(r) && !isValidRegexpMod(r) {
-			l.errorf("Line %d: illegal regex modifier (%s)", l.Line, string(r))
+		if !isBlank(r) && !isValidRegexpMod(r) && isAlphaNumeric(r) {
+			return l.errorf("Illegal regex modifier (%s)", string(r))
 		}
-		for isValidRegexpMod(r) {
-			mod += string(r)
+		for isValidRegexpMod(r) { // Flags are kept in the item value
 			r = l.next()
 		}
 		l.backup()
-		l.emit("ItemRegex")
+		l.emit(ItemRegex)
 		return lexText
 	}
 	return lexText
 }
 
 func scanVariable(l *Lexer) stateFn {
-	var r rune
-
-	if l.scanned() == "$" && isSpace(l.peek()) || isStar(l.peek()) || isEqual(l.peek()) { // $, $*
-		l.emit("ItemVariable")
-		return lexText
-	}
-
-	for r = l.peek(); !isBlank(r) && isAlphaNumeric(r); r = l.next() {
-	}
-
-	if isBlank(r) || !isAlphaNumeric(r) {
-		l.backup()
+	for isAlphaNumeric(l.peek()) { // $ alone is also a variable: $*, $ at 0
+		l.next()
 	}
-
-	l.emit("ItemVariable")
+	l.emit(ItemVariable)
 	return lexText
 }
 
 func scanQuote(l *Lexer) stateFn {
 	r := l.next() // We've aready got the "
 	for !isQuote(r) {
+		if isEOF(r) || isEndOfLine(r) {
+			l.backup()
+			return l.errorf("Expecting end of string and found end of line")
+		}
 		if isBackSlash(r) {
 			if isQuote(l.peek()) {
 				r = l.next() // Read the "
@@ -99,6 +89,8 @@ func scanQuote(l *Lexer) stateFn {
 				r = l.next() // Read the t
 			} else if l.peek() == 'n' {
 				r = l.next() // Read the n
+			} else if l.peek() == 'r' {
+				r = l.next() // Read the r
 			} else if l.peek() == 'x' {
 				r = l.next() // Read the x
 				// It needs a two hex characters
@@ -107,30 +99,50 @@ func scanQuote(l *Lexer) stateFn {
 					if isHexChar(l.peek()) {
 						r = l.next() // Read first hex char
 					} else {
-						l.errorf("Line %d: illegal escape sequence", l.Line)
+						l.errorf("Illegal escape sequence")
+						return skipQuote
 					}
 				} else {
-					l.errorf("Line %d: illegal escape sequence", l.Line)
+					l.errorf("Illegal escape sequence")
+					return skipQuote
 				}
 			} else {
-				l.errorf("Line %d: illegal escape sequence", l.Line)
+				l.errorf("Illegal escape sequence")
+				return skipQuote
 			}
 		}
 		r = l.next()
 	}
 	l.backup() // Remove the last "
-	l.emit("ItemString")
+	l.emit(ItemString)
+	tok := l.lastItem()
+	tok.Decoded = Unescape(tok.Value)
 	l.next()   // Consume the "
 	l.ignore() // Just ignore it
 	return lexText
 }
 
+// skipQuote discards the rest of a malformed string so scanning can go on
+func skipQuote(l *Lexer) stateFn {
+	for r := l.next(); !isQuote(r); r = l.next() {
+		if isEOF(r) || isEndOfLine(r) {
+			l.backup()
+			break
+		}
+		if isBackSlash(r) && isQuote(l.peek()) {
+			l.next()
+		}
+	}
+	l.ignore()
+	return lexText
+}
+
 func scanEqual(l *Lexer) stateFn {
 	if isEqual(l.peek()) {
 		_ = l.next()
-		l.emit("ItemEqualEqual")
+		l.emit(ItemEqualEqual)
 	} else {
-		l.emit("ItemEqual")
+		l.emit(ItemEqual)
 	}
 	return lexText
 }
@@ -138,9 +150,9 @@ func scanEqual(l *Lexer) stateFn {
 func scanNot(l *Lexer) stateFn {
 	if isEqual(l.peek()) {
 		_ = l.next()
-		l.emit("ItemNotEqual")
+		l.emit(ItemNotEqual)
 	} else {
-		l.emit("ItemNot")
+		l.emit(ItemNot)
 	}
 	return lexText
 }
@@ -151,9 +163,9 @@ func scanKeyword(l *Lexer) stateFn {
 
 	for !isBlank(r) && isAlphaNumeric(r) && length <= maxKeywordLength { // we still have content
 		length++
-		if isKeyword(l.scanned()) {
+		if kind, ok := keywords[l.scanned()]; ok {
 			if !isAlphaNumeric(l.peek()) {
-				l.emit(fmt.Sprintf("ItemKW%s", strings.Title(l.scanned())))
+				l.emit(kind)
 				return lexText
 			}
 		}
@@ -168,118 +180,212 @@ func scanKeyword(l *Lexer) stateFn {
 		l.next()
 	}
 
+	if isDigit(rune(l.scanned()[0])) && !l.inHex { // hex bytes are left as they are
+		return scanNumber
+	}
 	if _, err := strconv.Atoi(l.scanned()); err == nil {
-		l.emit("ItemIntNumber")
+		l.emit(ItemIntNumber)
 		return lexText
 	}
 
-	l.emit("ItemIdentifier")
+	l.emit(ItemIdentifier)
 	return lexText
 }
 
+// scanNumber emits the word scanned by scanKeyword as a number along with
+// its value: a decimal integer with an optional KB or MB suffix, an
+// hexadecimal (0x) or octal (0o) integer, or a float. Other words starting
+// with a digit are identifiers.
+func scanNumber(l *Lexer) stateFn {
+	if isDecimal(l.scanned()) && l.peek() == '.' && isDigit(rune(l.peekByte(1))) {
+		l.next() // Read the .
+		for isDigit(l.peek()) {
+			l.next()
+		}
+		f, err := strconv.ParseFloat(l.scanned(), 64)
+		if err != nil {
+			return l.errorf("Float %s out of range", l.scanned())
+		}
+		l.emit(ItemFloatNumber)
+		l.lastItem().Float = f
+		return lexText
+	}
+
+	n, ok, err := parseInt(l.scanned())
+	if !ok {
+		l.emit(ItemIdentifier)
+		return lexText
+	}
+	if err != nil {
+		return l.errorf("Integer %s out of range", l.scanned())
+	}
+	l.emit(ItemIntNumber)
+	l.lastItem().Int = n
+	return lexText
+}
+
+// parseInt returns the value of an integer written as Yara does. ok is
+// false when s is not an integer, err is set when it is out of range.
+func parseInt(s string) (n int64, ok bool, err error) {
+	base, mult := 10, int64(1)
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		base, s = 16, s[2:]
+	case strings.HasPrefix(s, "0o"):
+		base, s = 8, s[2:]
+	case strings.HasSuffix(s, "KB"):
+		mult, s = 1024, s[:len(s)-2]
+	case strings.HasSuffix(s, "MB"):
+		mult, s = 1024*1024, s[:len(s)-2]
+	}
+	if s == "" || (base == 10 && !isDecimal(s)) {
+		return 0, false, nil
+	}
+	n, err = strconv.ParseInt(s, base, 64)
+	if numErr, isNumErr := err.(*strconv.NumError); isNumErr && numErr.Err == strconv.ErrSyntax {
+		return 0, false, nil
+	}
+	if err == nil && n > math.MaxInt64/mult {
+		err = strconv.ErrRange
+	}
+	return n * mult, true, err
+}
+
+// Unescape decodes the escape sequences of a text string: \", \\, \t,
+// \n, \r and \xHH. Other characters after a backslash are kept as they are.
+func Unescape(value string) string {
+	var res []byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '\\' || i+1 == len(value) {
+			res = append(res, c)
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n':
+			res = append(res, '\n')
+		case 'r':
+			res = append(res, '\r')
+		case 't':
+			res = append(res, '\t')
+		case 'x':
+			if i+2 < len(value) {
+				if n, err := strconv.ParseUint(value[i+1:i+3], 16, 8); err == nil {
+					res = append(res, byte(n))
+					i += 2
+				}
+			}
+		default:
+			res = append(res, value[i])
+		}
+	}
+	return string(res)
+}
+
 func scanColon(l *Lexer) stateFn {
-	l.emit("ItemColon")
+	l.emit(ItemColon)
 	return lexText
 }
 
 func scanOpenCurly(l *Lexer) stateFn {
-	l.emit("ItemOCurly")
+	l.emit(ItemOCurly)
 	return lexText
 }
 
 func scanCloseCurly(l *Lexer) stateFn {
-	l.emit("ItemCCurly")
+	l.emit(ItemCCurly)
 	return lexText
 }
 
 func scanOpenSqrt(l *Lexer) stateFn {
-	l.emit("ItemOSqrt")
+	l.emit(ItemOSqrt)
 	return lexText
 }
 
 func scanCloseSqrt(l *Lexer) stateFn {
-	l.emit("ItemCSqrt")
+	l.emit(ItemCSqrt)
 	return lexText
 }
 
 func scanOpenBra(l *Lexer) stateFn {
-	l.emit("ItemOBracket")
+	l.emit(ItemOBracket)
 	return lexText
 }
 
 func scanCloseBra(l *Lexer) stateFn {
-	l.emit("ItemCBracket")
+	l.emit(ItemCBracket)
 	return lexText
 }
 
 func scanQMark(l *Lexer) stateFn {
-	l.emit("ItemQMark")
+	l.emit(ItemQMark)
 	return lexText
 }
 
 func scanDash(l *Lexer) stateFn {
-	l.emit("ItemDash")
+	l.emit(ItemDash)
 	return lexText
 }
 
 func scanPipe(l *Lexer) stateFn {
-	l.emit("ItemPipe")
+	l.emit(ItemPipe)
 	return lexText
 }
 
 func scanHash(l *Lexer) stateFn {
-	l.emit("ItemHash")
+	l.emit(ItemHash)
 	return lexText
 }
 
 func scanDot(l *Lexer) stateFn {
 	if isDot(l.peek()) {
 		_ = l.next()
-		l.emit("ItemDotDot")
+		l.emit(ItemDotDot)
 	} else {
-		l.emit("ItemDot")
+		l.emit(ItemDot)
 	}
 	return lexText
 }
 
 func scanStar(l *Lexer) stateFn {
-	l.emit("ItemStar")
+	l.emit(ItemStar)
 	return lexText
 }
 
 func scanCaret(l *Lexer) stateFn {
-	l.emit("ItemCaret")
+	l.emit(ItemCaret)
 	return lexText
 }
 
 func scanPlusOrMinus(l *Lexer) stateFn {
 	if l.scanned() == "-" {
-		l.emit("ItemDash")
+		l.emit(ItemDash)
 	} else {
-		l.emit("ItemPlus")
+		l.emit(ItemPlus)
 	}
 	return lexText
 }
 
 func scanComma(l *Lexer) stateFn {
-	l.emit("ItemComma")
+	l.emit(ItemComma)
 	return lexText
 }
 
 func scanAt(l *Lexer) stateFn {
-	l.emit("ItemAt")
+	l.emit(ItemAt)
 	return lexText
 }
 
 func scanGrater(l *Lexer) stateFn {
 	if isEqual(l.peek()) {
 		_ = l.next()
-		l.emit("ItemGraterEqual")
+		l.emit(ItemGraterEqual)
 	} else if isGrater(l.peek()) {
 		_ = l.next()
-		l.emit("ItemRightShift")
+		l.emit(ItemRightShift)
 	} else {
-		l.emit("ItemGrater")
+		l.emit(ItemGrater)
 	}
 	return lexText
 }
@@ -287,27 +393,32 @@ func scanGrater(l *Lexer) stateFn {
 func scanLess(l *Lexer) stateFn {
 	if isEqual(l.peek()) {
 		_ = l.next()
-		l.emit("ItemLessEqual")
+		l.emit(ItemLessEqual)
 	} else if isLess(l.peek()) {
 		_ = l.next()
-		l.emit("ItemLeftShift")
+		l.emit(ItemLeftShift)
 	} else {
-		l.emit("ItemLess")
+		l.emit(ItemLess)
 	}
 	return lexText
 }
 
 func scanAnd(l *Lexer) stateFn {
-	l.emit("ItemAnd")
+	l.emit(ItemAnd)
 	return lexText
 }
 
 func scanPercent(l *Lexer) stateFn {
-	l.emit("ItemPercent")
+	l.emit(ItemPercent)
+	return lexText
+}
+
+func scanBackSlash(l *Lexer) stateFn {
+	l.emit(ItemBackSlash)
 	return lexText
 }
 
 func scanBitNot(l *Lexer) stateFn {
-	l.emit("ItemBitNot")
+	l.emit(ItemBitNot)
 	return lexText
 }
@@ -0,0 +1,91 @@
+package lexic
+
+import "unicode/utf8"
+
+// TokenizeTrivia returns every token of input, for syntax highlighting and
+// editors. Unlike Tokenize it keeps the trivia: comments, blanks as
+// ItemSpace tokens and the text skipped after lexical errors as ItemInvalid
+// tokens, so the Raw text of the tokens put together is input. Scanning goes
+// on after lexical errors and the first one is returned with the tokens.
+// The final EOF token is not included.
+func TokenizeTrivia(input string) ([]Token, error) {
+	l := New("", input)
+	var (
+		tokens []Token
+		first  error
+	)
+	end := 0                                     // where the last token ends
+	at := position{line: 1, column: 1, runes: 1} // and its line and columns
+	for {
+		tok, err := l.Next()
+		if tok.Kind == ItemError {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		start, tokEnd := tok.Span()
+		if start > end {
+			tokens, at = appendGap(tokens, input[end:start], end, at)
+		}
+		if tok.Kind == ItemEOF {
+			return tokens, first
+		}
+		tokens = append(tokens, tok)
+		end = tokEnd
+		at = position{line: tok.EndLine, column: tok.EndColumn, runes: tok.EndRuneColumn}
+	}
+}
+
+// position is a line and a column in bytes and in runes
+type position struct {
+	line, column, runes int
+}
+
+// appendGap appends the text found between two tokens at offset pos, as
+// ItemSpace tokens for its blanks and ItemInvalid tokens for the rest. It
+// returns the position where the text ends.
+func appendGap(tokens []Token, text string, pos int, at position) ([]Token, position) {
+	for len(text) > 0 {
+		blank := isBlank(rune(text[0]))
+		n := 1
+		for n < len(text) && isBlank(rune(text[n])) == blank {
+			n++
+		}
+		kind := ItemInvalid
+		if blank {
+			kind = ItemSpace
+		}
+		end := at.advance(text[:n])
+		tokens = append(tokens, Token{
+			Kind:          kind,
+			Category:      kindCategory(kind, ""),
+			Value:         text[:n],
+			Raw:           text[:n],
+			Pos:           pos,
+			Line:          at.line,
+			Column:        at.column,
+			RuneColumn:    at.runes,
+			EndLine:       end.line,
+			EndColumn:     end.column,
+			EndRuneColumn: end.runes,
+		})
+		text, pos, at = text[n:], pos+n, end
+	}
+	return tokens, at
+}
+
+// advance returns the position found after text, starting at p
+func (p position) advance(text string) position {
+	for len(text) > 0 {
+		r, w := utf8.DecodeRuneInString(text)
+		if r == '\n' {
+			p.line, p.column, p.runes = p.line+1, 1, 1
+		} else {
+			p.column += w
+			p.runes++
+		}
+		text = text[w:]
+	}
+	return p
+}
@@ -0,0 +1,264 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+func init() {
+	Register(func() Check { return &requiredMeta{Fields: []string{"author", "date", "description"}} })
+	Register(func() Check { return &metaDate{Key: "date", Layout: "2006-01-02"} })
+	Register(func() Check { return newRuleName(`^[A-Za-z][A-Za-z0-9_]*$`) })
+	Register(func() Check { return &maxStrings{Max: 50} })
+	Register(func() Check { return &nocaseShortString{MinLength: 4} })
+	Register(func() Check { return &hexLeadingWildcard{} })
+	Register(func() Check { return &shortAtom{MinLength: 3} })
+}
+
+// requiredMeta reports rules missing any of the meta fields in Fields
+type requiredMeta struct {
+	Fields []string `json:"fields"`
+}
+
+func (c *requiredMeta) Name() string       { return "required-meta" }
+func (c *requiredMeta) Severity() Severity { return SeverityWarning }
+func (c *requiredMeta) Description() string {
+	return "Rules must define the required meta fields"
+}
+
+func (c *requiredMeta) Configure(options json.RawMessage) error {
+	return json.Unmarshal(options, c)
+}
+
+func (c *requiredMeta) Check(rule grammar.RuleDef) []Problem {
+	var res []Problem
+	for _, field := range c.Fields {
+		if len(rule.Meta.Get(field)) == 0 {
+			res = append(res, Problem{Msg: fmt.Sprintf("Rule %s has no %s meta field", rule.Name, field)})
+		}
+	}
+	return res
+}
+
+// metaDate reports dates not written following Layout, a layout of the
+// time package
+type metaDate struct {
+	Key    string `json:"key"`
+	Layout string `json:"layout"`
+}
+
+func (c *metaDate) Name() string       { return "meta-date" }
+func (c *metaDate) Severity() Severity { return SeverityWarning }
+func (c *metaDate) Description() string {
+	return "Date meta fields must follow the configured layout"
+}
+
+func (c *metaDate) Configure(options json.RawMessage) error {
+	return json.Unmarshal(options, c)
+}
+
+func (c *metaDate) Check(rule grammar.RuleDef) []Problem {
+	var res []Problem
+	for _, meta := range rule.Meta.Get(c.Key) {
+		if _, err := time.Parse(c.Layout, meta.Value); err != nil {
+			res = append(res, Problem{
+				Pos: metaPosition(meta),
+				Msg: fmt.Sprintf("Meta %s %q does not follow the layout %s", c.Key, meta.Value, c.Layout),
+			})
+		}
+	}
+	return res
+}
+
+// ruleName reports rule names not matching Pattern
+type ruleName struct {
+	Pattern string `json:"pattern"`
+	re      *regexp.Regexp
+}
+
+func newRuleName(pattern string) *ruleName {
+	return &ruleName{Pattern: pattern, re: regexp.MustCompile(pattern)}
+}
+
+func (c *ruleName) Name() string       { return "rule-name" }
+func (c *ruleName) Severity() Severity { return SeverityWarning }
+func (c *ruleName) Description() string {
+	return "Rule names must match the configured pattern"
+}
+
+func (c *ruleName) Configure(options json.RawMessage) error {
+	if err := json.Unmarshal(options, c); err != nil {
+		return err
+	}
+	re, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return err
+	}
+	c.re = re
+	return nil
+}
+
+func (c *ruleName) Check(rule grammar.RuleDef) []Problem {
+	if c.re.MatchString(rule.Name) {
+		return nil
+	}
+	return []Problem{{Msg: fmt.Sprintf("Rule name %s does not match %s", rule.Name, c.Pattern)}}
+}
+
+// maxStrings reports rules defining more than Max strings
+type maxStrings struct {
+	Max int `json:"max"`
+}
+
+func (c *maxStrings) Name() string       { return "max-strings" }
+func (c *maxStrings) Severity() Severity { return SeverityWarning }
+func (c *maxStrings) Description() string {
+	return "Rules must not define more strings than the configured maximum"
+}
+
+func (c *maxStrings) Configure(options json.RawMessage) error {
+	return json.Unmarshal(options, c)
+}
+
+func (c *maxStrings) Check(rule grammar.RuleDef) []Problem {
+	if len(rule.Strings) <= c.Max {
+		return nil
+	}
+	return []Problem{{Msg: fmt.Sprintf("Rule %s defines %d strings, more than %d", rule.Name, len(rule.Strings), c.Max)}}
+}
+
+// nocaseShortString reports nocase text strings shorter than MinLength bytes
+type nocaseShortString struct {
+	MinLength int `json:"min_length"`
+}
+
+func (c *nocaseShortString) Name() string       { return "nocase-short-string" }
+func (c *nocaseShortString) Severity() Severity { return SeverityWarning }
+func (c *nocaseShortString) Description() string {
+	return "Short text strings must not use the nocase modifier"
+}
+
+func (c *nocaseShortString) Configure(options json.RawMessage) error {
+	return json.Unmarshal(options, c)
+}
+
+func (c *nocaseShortString) Check(rule grammar.RuleDef) []Problem {
+	var res []Problem
+	for _, str := range rule.Strings {
+		if str.Typ != grammar.StringString || !hasModifier(str, "nocase") {
+			continue
+		}
+		if n := len(str.Bytes); n < c.MinLength {
+			res = append(res, Problem{
+				Pos: stringPosition(str),
+				Msg: fmt.Sprintf("String %s is %d bytes long and uses nocase", str.Name, n),
+			})
+		}
+	}
+	return res
+}
+
+// hexLeadingWildcard reports hex strings starting with a wildcard
+type hexLeadingWildcard struct{}
+
+func (c *hexLeadingWildcard) Name() string       { return "hex-leading-wildcard" }
+func (c *hexLeadingWildcard) Severity() Severity { return SeverityWarning }
+func (c *hexLeadingWildcard) Description() string {
+	return "Hex strings must not start with a wildcard"
+}
+
+func (c *hexLeadingWildcard) Check(rule grammar.RuleDef) []Problem {
+	var res []Problem
+	for _, str := range rule.Strings {
+		if str.Typ != grammar.StringHex || len(str.Hex) == 0 {
+			continue
+		}
+		if kind := str.Hex[0].Kind; kind == grammar.HexWildcard || kind == grammar.HexMasked {
+			res = append(res, Problem{
+				Pos: stringPosition(str),
+				Msg: fmt.Sprintf("Hex string %s starts with a wildcard", str.Name),
+			})
+		}
+	}
+	return res
+}
+
+// shortAtom reports text and hex strings whose longest sequence of fixed
+// bytes, from which Yara picks the atoms it looks for, is shorter than
+// MinLength. Such strings slow scanning down.
+type shortAtom struct {
+	MinLength int `json:"min_length"`
+}
+
+func (c *shortAtom) Name() string       { return "short-atom" }
+func (c *shortAtom) Severity() Severity { return SeverityWarning }
+func (c *shortAtom) Description() string {
+	return "Strings must contain enough fixed bytes to be scanned efficiently"
+}
+
+func (c *shortAtom) Configure(options json.RawMessage) error {
+	return json.Unmarshal(options, c)
+}
+
+func (c *shortAtom) Check(rule grammar.RuleDef) []Problem {
+	var res []Problem
+	for _, str := range rule.Strings {
+		n := -1
+		switch str.Typ {
+		case grammar.StringString:
+			n = len(str.Bytes)
+		case grammar.StringHex:
+			n = longestFixed(str.Hex)
+		}
+		if n >= 0 && n < c.MinLength {
+			res = append(res, Problem{
+				Pos: stringPosition(str),
+				Msg: fmt.Sprintf("String %s has at most %d fixed bytes in a row", str.Name, n),
+			})
+		}
+	}
+	return res
+}
+
+// longestFixed returns the length of the longest run of fixed bytes in hex
+func longestFixed(hex grammar.HexPattern) int {
+	longest, run := 0, 0
+	for _, tok := range hex {
+		if tok.Kind == grammar.HexByte && !tok.Not {
+			run++
+			if run > longest {
+				longest = run
+			}
+			continue
+		}
+		run = 0
+	}
+	return longest
+}
+
+func hasModifier(str grammar.StringDef, name string) bool {
+	for _, m := range str.Modifiers {
+		if m.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func metaPosition(meta grammar.MetaDef) *grammar.Position {
+	if meta.Span == nil {
+		return nil
+	}
+	return &meta.Span.Start
+}
+
+func stringPosition(str grammar.StringDef) *grammar.Position {
+	if str.Span == nil {
+		return nil
+	}
+	return &str.Span.Start
+}
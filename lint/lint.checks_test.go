@@ -0,0 +1,61 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEachCheck(t *testing.T) {
+	tests := []struct {
+		check, options string
+		src            string
+		want           []string
+	}{
+		{"required-meta", `{"fields": ["author", "date"]}`,
+			"rule a {\n meta:\n  author = \"me\"\n condition: true\n}", []string{
+				"test.yar:1:1: warning: Rule a has no date meta field [required-meta]",
+			}},
+		{"meta-date", "",
+			"rule a {\n meta:\n  date = \"2017-04-07\"\n  date = \"07/04/2017\"\n condition: true\n}", []string{
+				"test.yar:4:3: warning: Meta date \"07/04/2017\" does not follow the layout 2006-01-02 [meta-date]",
+			}},
+		{"meta-date", `{"key": "created", "layout": "02/01/2006"}`,
+			"rule a {\n meta:\n  created = \"07/04/2017\"\n condition: true\n}", nil},
+		{"rule-name", "",
+			"rule Good_1 { condition: true }\nrule _bad { condition: true }", []string{
+				"test.yar:2:1: warning: Rule name _bad does not match ^[A-Za-z][A-Za-z0-9_]*$ [rule-name]",
+			}},
+		{"rule-name", `{"pattern": "^MAL_"}`,
+			"rule MAL_a { condition: true }\nrule b { condition: true }", []string{
+				"test.yar:2:1: warning: Rule name b does not match ^MAL_ [rule-name]",
+			}},
+		{"max-strings", `{"max": 1}`,
+			"rule a {\n strings:\n  $a = \"abcd\"\n  $b = \"efgh\"\n condition: any of them\n}", []string{
+				"test.yar:1:1: warning: Rule a defines 2 strings, more than 1 [max-strings]",
+			}},
+		{"nocase-short-string", "",
+			"rule a {\n strings:\n  $a = \"ab\" nocase\n  $b = \"\\x41\\x42\\x43\\x44\" nocase\n  $c = \"ab\"\n condition: any of them\n}", []string{
+				"test.yar:3:3: warning: String $a is 2 bytes long and uses nocase [nocase-short-string]",
+			}},
+		{"hex-leading-wildcard", "",
+			"rule a {\n strings:\n  $a = { ?? 5A 90 }\n  $b = { ?A 5A 90 }\n  $c = { 4D [2] 90 }\n condition: any of them\n}", []string{
+				"test.yar:3:3: warning: Hex string $a starts with a wildcard [hex-leading-wildcard]",
+				"test.yar:4:3: warning: Hex string $b starts with a wildcard [hex-leading-wildcard]",
+			}},
+		{"short-atom", "",
+			"rule a {\n strings:\n  $a = \"ab\"\n  $b = \"\\x41\\x42\\x43\"\n  $c = { 4D ?? 5A ?? 90 }\n  $d = { 4D 5A 90 [1-2] 00 }\n  $e = /a/\n condition: any of them\n}", []string{
+				"test.yar:3:3: warning: String $a has at most 2 fixed bytes in a row [short-atom]",
+				"test.yar:5:3: warning: String $c has at most 1 fixed bytes in a row [short-atom]",
+			}},
+		{"short-atom", `{"min_length": 2}`,
+			"rule a {\n strings:\n  $a = \"ab\"\n  $c = { 4D ?? 5A }\n condition: any of them\n}", []string{
+				"test.yar:4:3: warning: String $c has at most 1 fixed bytes in a row [short-atom]",
+			}},
+	}
+	for _, test := range tests {
+		got := lintSource(t, only(t, test.check, test.options), test.src)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s: got %q, want %q", test.check, test.options, got, test.want)
+		}
+	}
+}
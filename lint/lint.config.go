@@ -0,0 +1,35 @@
+package lint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+// Config enables, disables and sets the severity and the options of the
+// checks, by name. Checks not listed run with their defaults.
+type Config struct {
+	Checks map[string]CheckConfig `json:"checks"`
+}
+
+// CheckConfig is the configuration of a check
+type CheckConfig struct {
+	Enabled  *bool           `json:"enabled,omitempty"`
+	Severity Severity        `json:"severity,omitempty"`
+	Options  json.RawMessage `json:"options,omitempty"`
+}
+
+// LoadConfig reads a JSON configuration file such as
+//
+//	{"checks": {"max-strings": {"severity": "error", "options": {"max": 20}},
+//	            "rule-name": {"enabled": false}}}
+func LoadConfig(fileName string) (*Config, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,220 @@
+// Package lint checks Yara rules against style guides. Checks are looked up
+// in a registry, configured from a file and can be suppressed with comments
+// in the rules.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Yara-Rules/yago/grammar"
+	"github.com/Yara-Rules/yago/sarif"
+)
+
+// Severity tells how serious the problems reported by a check are
+type Severity string
+
+// Severities of the checks
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Check looks for a kind of problem in a rule
+type Check interface {
+	Name() string
+	Description() string
+	Severity() Severity // used unless the configuration sets another one
+	Check(rule grammar.RuleDef) []Problem
+}
+
+// Configurable is implemented by the checks which accept options. Configure
+// receives the options of the check as written in the configuration file.
+type Configurable interface {
+	Configure(options json.RawMessage) error
+}
+
+// Problem is something a check found in a rule. Pos is nil when the problem
+// is about the whole rule.
+type Problem struct {
+	Pos *grammar.Position
+	Msg string
+}
+
+// Diagnostic is a problem found while linting a file
+type Diagnostic struct {
+	Check      string   `json:"check"`
+	Severity   Severity `json:"severity"`
+	FileName   string   `json:"file_name"`
+	Rule       string   `json:"rule,omitempty"`
+	Line       int      `json:"line"`
+	Column     int      `json:"column"`
+	RuneColumn int      `json:"rune_column,omitempty"`
+	Offset     int      `json:"offset"`
+	Msg        string   `json:"msg"`
+}
+
+// String returns the diagnostic formatted as file:line:column: severity: message [check]
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.FileName, d.Line, d.Column, d.Severity, d.Msg, d.Check)
+}
+
+// FromParseError turns an error found while parsing a file into a
+// diagnostic, whose check is the kind of the error
+func FromParseError(err *grammar.ParseError) Diagnostic {
+	check := err.Kind
+	if err.Code != "" {
+		check = err.Code
+	}
+	severity := SeverityError
+	if err.IsWarning() {
+		severity = SeverityWarning
+	}
+	return Diagnostic{
+		Check:      check,
+		Severity:   severity,
+		FileName:   err.FileName,
+		Rule:       err.Rule,
+		Line:       err.Line,
+		Column:     err.Column,
+		RuneColumn: err.RuneColumn,
+		Offset:     err.Offset,
+		Msg:        err.Msg,
+	}
+}
+
+var registry = map[string]func() Check{}
+
+// Register adds a check to the registry. new is called to get a fresh
+// check, with its default options, for every Linter. It panics when a check
+// with the same name is already registered.
+func Register(new func() Check) {
+	name := new().Name()
+	if _, ok := registry[name]; ok {
+		panic("lint: check " + name + " registered twice")
+	}
+	registry[name] = new
+}
+
+// Checks returns the registered checks, sorted by name
+func Checks() []Check {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = registry[name]()
+	}
+	return checks
+}
+
+// Linter runs the enabled checks with their configured severity
+type Linter struct {
+	checks     []Check
+	severities map[string]Severity
+}
+
+// New returns a Linter running every registered check configured by cfg.
+// A nil cfg runs every check with its default options.
+func New(cfg *Config) (*Linter, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	for name := range cfg.Checks {
+		if _, ok := registry[name]; !ok {
+			return nil, fmt.Errorf("Unknown lint check %s", name)
+		}
+	}
+	l := &Linter{severities: map[string]Severity{}}
+	for _, check := range Checks() {
+		name := check.Name()
+		conf := cfg.Checks[name]
+		if conf.Enabled != nil && !*conf.Enabled {
+			continue
+		}
+		severity := check.Severity()
+		switch conf.Severity {
+		case "":
+		case SeverityError, SeverityWarning, SeverityInfo:
+			severity = conf.Severity
+		default:
+			return nil, fmt.Errorf("Unknown severity %s for lint check %s", conf.Severity, name)
+		}
+		if len(conf.Options) > 0 {
+			c, ok := check.(Configurable)
+			if !ok {
+				return nil, fmt.Errorf("Lint check %s has no options", name)
+			}
+			if err := c.Configure(conf.Options); err != nil {
+				return nil, fmt.Errorf("Invalid options for lint check %s: %s", name, err)
+			}
+		}
+		l.checks = append(l.checks, check)
+		l.severities[name] = severity
+	}
+	return l, nil
+}
+
+// Lint runs the checks on rules. source returns the contents of the file a
+// rule was read from, where suppression comments are looked for, or nil
+// when it is unknown. source may be nil.
+func (l *Linter) Lint(rules []grammar.RuleDef, source func(fileName string) []byte) []Diagnostic {
+	var diags []Diagnostic
+	suppressed := map[string]suppressions{}
+	for _, rule := range rules {
+		sup, ok := suppressed[rule.File]
+		if !ok && source != nil {
+			if src := source(rule.File); src != nil {
+				sup = parseSuppressions(string(src))
+			}
+			suppressed[rule.File] = sup
+		}
+		for _, check := range l.checks {
+			for _, problem := range check.Check(rule) {
+				d := Diagnostic{
+					Check:    check.Name(),
+					Severity: l.severities[check.Name()],
+					FileName: rule.File,
+					Rule:     rule.Name,
+					Msg:      problem.Msg,
+				}
+				pos := problem.Pos
+				if pos == nil && rule.Span != nil {
+					pos = &rule.Span.Start
+				}
+				if pos != nil {
+					d.Line, d.Column, d.RuneColumn, d.Offset = pos.Line, pos.Column, pos.RuneColumn, pos.Offset
+				}
+				if !sup.match(d, rule) {
+					diags = append(diags, d)
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// SARIF returns diags as a SARIF log, describing the checks of the linter
+func (l *Linter) SARIF(diags []Diagnostic, version string) *sarif.Log {
+	log := sarif.New(version)
+	for _, check := range l.checks {
+		log.AddRule(check.Name(), check.Description())
+	}
+	for _, d := range diags {
+		log.AddRule(d.Check, "")
+		log.AddResult(d.Check, d.Severity.sarifLevel(), d.Msg, d.FileName, d.Line, d.RuneColumn)
+	}
+	return log
+}
+
+// sarifLevel returns the SARIF level matching s
+func (s Severity) sarifLevel() string {
+	if s == SeverityInfo {
+		return "note"
+	}
+	return string(s)
+}
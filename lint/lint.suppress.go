@@ -0,0 +1,89 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
+	"github.com/Yara-Rules/yago/lexic"
+)
+
+// Suppression comments, optionally followed by the checks they apply to:
+//
+//	// yago-lint:ignore       the line of the comment, and the next one when
+//	//                        the comment is alone on its line
+//	// yago-lint:ignore-rule  the rule the comment is in or right above
+//	// yago-lint:ignore-file  the whole file
+var directive = regexp.MustCompile(`yago-lint:(ignore(?:-rule|-file)?)\b([^*\n]*)`)
+
+// suppression is a suppression comment found in a file. An empty list of
+// checks applies to all of them.
+type suppression struct {
+	scope              string
+	startLine, endLine int
+	alone              bool // nothing but blanks before the comment on its line
+	checks             []string
+}
+
+type suppressions []suppression
+
+// parseSuppressions returns the suppression comments of src
+func parseSuppressions(src string) suppressions {
+	var res suppressions
+	items, _ := lexic.Tokenize(src) // comments after a lexical error are ignored
+	for _, item := range items {
+		if item.Kind != lexic.ItemComment {
+			continue
+		}
+		m := directive.FindStringSubmatch(item.GetValue())
+		if m == nil {
+			continue
+		}
+		start := item.GetPos()
+		value := strings.TrimRight(item.GetValue(), "\r\n")
+		lineStart := strings.LastIndex(src[:start], "\n") + 1
+		res = append(res, suppression{
+			scope:     m[1],
+			startLine: 1 + strings.Count(src[:start], "\n"),
+			endLine:   1 + strings.Count(src[:start+len(value)], "\n"),
+			alone:     strings.TrimSpace(src[lineStart:start]) == "",
+			checks:    strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }),
+		})
+	}
+	return res
+}
+
+// match tells whether d, found in rule, is suppressed
+func (s suppressions) match(d Diagnostic, rule grammar.RuleDef) bool {
+	for _, sup := range s {
+		if len(sup.checks) > 0 && !inList(d.Check, sup.checks) {
+			continue
+		}
+		switch sup.scope {
+		case "ignore-file":
+			return true
+		case "ignore-rule":
+			if rule.Span != nil && sup.endLine >= rule.Span.Start.Line-1 && sup.startLine <= rule.Span.End.Line {
+				return true
+			}
+		default:
+			end := sup.endLine
+			if sup.alone {
+				end++
+			}
+			if d.Line >= sup.startLine && d.Line <= end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inList(s string, list []string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
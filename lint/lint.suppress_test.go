@@ -0,0 +1,39 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuppressions(t *testing.T) {
+	rule := func(comment, next string) string {
+		return "rule a {\n strings:\n  $a = \"ab\"" + comment + "\n  $b = \"cd\"" + next + "\n  $c = \"efgh\"\n condition: any of them\n}\n"
+	}
+	both := []string{
+		"test.yar:3:3: warning: String $a has at most 2 fixed bytes in a row [short-atom]",
+		"test.yar:4:3: warning: String $b has at most 2 fixed bytes in a row [short-atom]",
+	}
+	tests := []struct {
+		name, src string
+		want      []string
+	}{
+		{"none", rule("", ""), both},
+		{"same line", rule(" // yago-lint:ignore", ""), both[1:]},
+		{"listed check", rule(" // yago-lint:ignore short-atom", ""), both[1:]},
+		{"other check", rule(" // yago-lint:ignore rule-name", ""), both},
+		{"alone on its line", "rule a {\n strings:\n  // yago-lint:ignore\n  $a = \"ab\"\n  $b = \"cd\"\n condition: any of them\n}\n", []string{
+			"test.yar:5:3: warning: String $b has at most 2 fixed bytes in a row [short-atom]",
+		}},
+		{"rule above", "// yago-lint:ignore-rule short-atom\n" + rule("", "") + "rule b {\n strings:\n  $a = \"ab\"\n condition: $a\n}\n", []string{
+			"test.yar:11:3: warning: String $a has at most 2 fixed bytes in a row [short-atom]",
+		}},
+		{"in the rule", rule("", " /* yago-lint:ignore-rule */"), nil},
+		{"file", "/* yago-lint:ignore-file short-atom, rule-name */\n" + rule("", ""), nil},
+	}
+	for _, test := range tests {
+		got := lintSource(t, only(t, "short-atom", ""), test.src)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// lintSource parses src as test.yar and lints its rules with l
+func lintSource(t *testing.T, l *Linter, src string) []string {
+	t.Helper()
+	p := grammar.New("test.yar")
+	if err := p.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var res []string
+	for _, d := range l.Lint(p.Rules, func(string) []byte { return []byte(src) }) {
+		res = append(res, d.String())
+	}
+	return res
+}
+
+// only returns a Linter running the check name alone, with options unless
+// they are empty
+func only(t *testing.T, name, options string) *Linter {
+	t.Helper()
+	off := false
+	cfg := &Config{Checks: map[string]CheckConfig{}}
+	for _, check := range Checks() {
+		if check.Name() != name {
+			cfg.Checks[check.Name()] = CheckConfig{Enabled: &off}
+		}
+	}
+	if options != "" {
+		cfg.Checks[name] = CheckConfig{Options: []byte(options)}
+	}
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return l
+}
+
+func TestChecks(t *testing.T) {
+	var names []string
+	for _, check := range Checks() {
+		names = append(names, check.Name())
+		if check.Description() == "" {
+			t.Errorf("%s has no description", check.Name())
+		}
+	}
+	want := []string{"hex-leading-wildcard", "max-strings", "meta-date", "nocase-short-string", "required-meta", "rule-name", "short-atom"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Checks() = %v, want %v", names, want)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register did not panic on a check registered twice")
+		}
+	}()
+	Register(func() Check { return &maxStrings{} })
+}
+
+func TestConfig(t *testing.T) {
+	src := "rule a { condition: true }\n"
+	off, on := false, true
+	tests := []struct {
+		name string
+		cfg  *Config
+		want []string
+	}{
+		{"defaults", nil, []string{
+			"test.yar:1:1: warning: Rule a has no author meta field [required-meta]",
+			"test.yar:1:1: warning: Rule a has no date meta field [required-meta]",
+			"test.yar:1:1: warning: Rule a has no description meta field [required-meta]",
+		}},
+		{"disabled", &Config{Checks: map[string]CheckConfig{
+			"required-meta": {Enabled: &off},
+		}}, nil},
+		{"severity and options", &Config{Checks: map[string]CheckConfig{
+			"required-meta": {Enabled: &on, Severity: SeverityError, Options: []byte(`{"fields": ["owner"]}`)},
+		}}, []string{
+			"test.yar:1:1: error: Rule a has no owner meta field [required-meta]",
+		}},
+	}
+	for _, test := range tests {
+		l, err := New(test.cfg)
+		if err != nil {
+			t.Fatalf("%s: New: %v", test.name, err)
+		}
+		if got := lintSource(t, l, src); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		checks map[string]CheckConfig
+		want   string
+	}{
+		{map[string]CheckConfig{"no-such-check": {}}, "Unknown lint check no-such-check"},
+		{map[string]CheckConfig{"max-strings": {Severity: "fatal"}}, "Unknown severity fatal for lint check max-strings"},
+		{map[string]CheckConfig{"hex-leading-wildcard": {Options: []byte(`{}`)}}, "Lint check hex-leading-wildcard has no options"},
+		{map[string]CheckConfig{"max-strings": {Options: []byte(`{"max": "ten"}`)}}, "Invalid options for lint check max-strings"},
+		{map[string]CheckConfig{"rule-name": {Options: []byte(`{"pattern": "("}`)}}, "Invalid options for lint check rule-name"},
+	}
+	for _, test := range tests {
+		_, err := New(&Config{Checks: test.checks})
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("New(%v): error %v, want %s", test.checks, err, test.want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "lint.json")
+	data := `{"checks": {"max-strings": {"severity": "error", "options": {"max": 1}}, "rule-name": {"enabled": false}}}`
+	if err := ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(fileName)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if l.severities["max-strings"] != SeverityError {
+		t.Errorf("max-strings severity %s, want error", l.severities["max-strings"])
+	}
+	if _, ok := l.severities["rule-name"]; ok {
+		t.Error("rule-name enabled, want it disabled")
+	}
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("LoadConfig of a missing file: no error")
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	l := only(t, "max-strings", `{"max": 0}`)
+	src := "rule a {\n strings: $a = \"abcd\"\n condition: $a\n}\n"
+	p := grammar.New("test.yar")
+	if err := p.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+	log := l.SARIF(l.Lint(p.Rules, nil), "v1")
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "max-strings" {
+		t.Errorf("rules %+v, want max-strings", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 1 || run.Results[0].Level != "warning" {
+		t.Errorf("results %+v, want one warning", run.Results)
+	}
+}
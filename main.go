@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 
+	"github.com/Yara-Rules/yago/format"
+	"github.com/Yara-Rules/yago/grammar"
+	"github.com/Yara-Rules/yago/lint"
+	"github.com/Yara-Rules/yago/sarif"
 	"github.com/Yara-Rules/yago/yago"
 	docopt "github.com/docopt/docopt-go"
 )
@@ -25,19 +32,31 @@ func main() {
 	usage := `YaGo - Parsing Yara rules like a Gopher.
 
 Usage:
-  yago fileName <fileName> [ --validJSON ]
-  yago dirName <dirName> [ --validJSON ]
-  yago indexFile <indexFile> [ cwd <path> ] [ --validJSON ]
+  yago fileName <fileName> [ --validJSON ] [ --ast ] [ --format=<format> ] [ --diagnostics=<file> ]
+  yago dirName <dirName> [ --validJSON ] [ --ast ] [ --format=<format> ] [ --diagnostics=<file> ] [ --workers=<n> ]
+  yago indexFile <indexFile> [ cwd <path> ] [ --validJSON ] [ --ast ] [ --format=<format> ] [ --diagnostics=<file> ] [ --workers=<n> ]
   yago inputFile <inputFile> outputDir <outputDir> [ --overwrite ] [ --validJSON ]
   yago inputFile <inputFile> outputFile <outputFile> [ --overwrite ] [ --validJSON ]
+  yago fmt <source>... [ --write | --check ] [ --width=<n> ]
+  yago roundtrip <dirName>
+  yago check <source>... [ --format=<format> ]
+  yago lint <source>... [ --config=<file> ] [ --format=<format> ]
   yago -h | --help
   yago --version
 
 Options:
   -h --help             Show this screen.
+  --ast                 Include the condition expression tree in the JSON output [default: false].
+  --check               List files whose formatting differs and exit with status 1 [default: false].
+  --config=<file>       Lint configuration file.
+  --diagnostics=<file>  Write the diagnostics found while parsing to a file instead of stderr.
+  --format=<format>     Report diagnostics as text, json or sarif.
   --overwrite           Overwrites existing files [dafault: false].
   --validJSON           Print rules using a valid JSON format [dafault: false].
   --version             Show version.
+  --width=<n>           Line width used to wrap hex strings and conditions [default: 100].
+  --workers=<n>         Number of files parsed at once, one per CPU when 0 [default: 0].
+  --write               Write the formatted result back to the source files [default: false].
 `
 	version := printVersion()
 	arguments, _ := docopt.Parse(usage, nil, true, version, false)
@@ -50,8 +69,12 @@ Options:
 		validJSON := arguments["--validJSON"].(bool)
 		fileName := arguments["<fileName>"].(string)
 
-		res := yago.ProcessFile(fileName)
-		yago.GenerateOutputFromYara(res, validJSON)
+		res, err := yago.ProcessFile(fileName)
+		reportParse(res, err, arguments)
+		if !arguments["--ast"].(bool) {
+			yago.DropConditionAST(res)
+		}
+		checkErr(yago.GenerateOutputFromYara(res, validJSON))
 
 	} else if arguments["dirName"].(bool) {
 		if arguments["<dirName>"].(string) == "" {
@@ -61,8 +84,15 @@ Options:
 		validJSON := arguments["--validJSON"].(bool)
 		dirName := arguments["<dirName>"].(string)
 
-		res := yago.ProcessDir(dirName)
-		yago.GenerateOutputFromYara(res, validJSON)
+		res, err := yago.ProcessDirContext(context.Background(), dirName, workers(arguments))
+		failed := reportParse(res, err, arguments)
+		if !arguments["--ast"].(bool) {
+			yago.DropConditionAST(res)
+		}
+		checkErr(yago.GenerateOutputFromYara(res, validJSON))
+		if failed {
+			os.Exit(1)
+		}
 
 	} else if arguments["indexFile"].(bool) {
 		if arguments["<indexFile>"].(string) == "" {
@@ -76,8 +106,15 @@ Options:
 		validJSON := arguments["--validJSON"].(bool)
 		indexFile := arguments["<indexFile>"].(string)
 
-		res := yago.ProcessIndex(indexFile, cwd)
-		yago.GenerateOutputFromYara(res, validJSON)
+		res, err := yago.ProcessIndexContext(context.Background(), indexFile, cwd, workers(arguments))
+		failed := reportParse(res, err, arguments)
+		if !arguments["--ast"].(bool) {
+			yago.DropConditionAST(res)
+		}
+		checkErr(yago.GenerateOutputFromYara(res, validJSON))
+		if failed {
+			os.Exit(1)
+		}
 
 	} else if arguments["inputFile"].(bool) {
 		if arguments["<inputFile>"].(string) == "" {
@@ -95,8 +132,9 @@ Options:
 
 			outputDir := arguments["<outputDir>"].(string)
 
-			res := yago.ProcessInputFile(inputFile, validJSON)
-			yago.GenerateOutputToYaraDir(res, outputDir, overwrite)
+			res, err := yago.ProcessInputFile(inputFile, validJSON)
+			checkErr(err)
+			checkErr(yago.GenerateOutputToYaraDir(res, outputDir, overwrite))
 
 		} else if arguments["outputFile"].(bool) {
 			if arguments["<outputFile>"].(string) == "" {
@@ -105,9 +143,116 @@ Options:
 
 			outputFile := arguments["<outputFile>"].(string)
 
-			res := yago.ProcessInputFile(inputFile, validJSON)
+			res, err := yago.ProcessInputFile(inputFile, validJSON)
+			checkErr(err)
 			uniq := yago.UnifyRules(res)
-			yago.GenerateOutputToYaraFile(uniq, outputFile, overwrite)
+			checkErr(yago.GenerateOutputToYaraFile(uniq, outputFile, overwrite))
+		}
+
+	} else if arguments["fmt"].(bool) {
+		width, err := strconv.Atoi(arguments["--width"].(string))
+		if err != nil {
+			errAndExit("ERROR: The line width must be a number.")
+		}
+		opts := format.DefaultOptions
+		opts.LineWidth = width
+
+		write := arguments["--write"].(bool)
+		check := arguments["--check"].(bool)
+
+		files, err := yago.RuleFiles(arguments["<source>"].([]string))
+		checkErr(err)
+
+		unformatted := false
+		for _, fileName := range files {
+			res, changed, err := yago.FormatFile(fileName, opts, write)
+			checkErr(err)
+			if check {
+				if changed {
+					os.Stdout.WriteString(fileName + "\n")
+					unformatted = true
+				}
+			} else if !write {
+				os.Stdout.Write(res)
+			}
+		}
+		if unformatted {
+			os.Exit(1)
+		}
+
+	} else if arguments["roundtrip"].(bool) {
+		files, err := yago.RuleFiles([]string{arguments["<dirName>"].(string)})
+		checkErr(err)
+
+		failed := false
+		for _, fileName := range files {
+			diff, err := yago.RoundTrip(fileName)
+			if err != nil {
+				os.Stdout.WriteString(err.Error() + "\n")
+				failed = true
+			}
+			for _, name := range diff {
+				os.Stdout.WriteString(fmt.Sprintf("%s: %s changed\n", fileName, name))
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+
+	} else if arguments["check"].(bool) {
+		files, err := yago.RuleFiles(arguments["<source>"].([]string))
+		checkErr(err)
+
+		var diags []*grammar.ParseError
+		for _, fileName := range files {
+			res, err := yago.CheckFile(fileName)
+			checkErr(err)
+			diags = append(diags, res...)
+		}
+		format := reportFormat(arguments)
+		if format == "" {
+			format = "text"
+		}
+		writeDiagnostics(os.Stdout, diags, format)
+		if status := checkStatus(diags); status != 0 {
+			os.Exit(status)
+		}
+
+	} else if arguments["lint"].(bool) {
+		var cfg *lint.Config
+		if fileName, ok := arguments["--config"].(string); ok {
+			var err error
+			cfg, err = lint.LoadConfig(fileName)
+			checkErr(err)
+		}
+		linter, err := lint.New(cfg)
+		checkErr(err)
+
+		files, err := yago.RuleFiles(arguments["<source>"].([]string))
+		checkErr(err)
+
+		diags := []lint.Diagnostic{}
+		for _, fileName := range files {
+			res, err := yago.LintFile(fileName, linter)
+			checkErr(err)
+			diags = append(diags, res...)
+		}
+
+		switch reportFormat(arguments) {
+		case "", "text":
+			for _, d := range diags {
+				os.Stdout.WriteString(d.String() + "\n")
+			}
+		case "json":
+			writeJSON(os.Stdout, diags)
+		case "sarif":
+			writeJSON(os.Stdout, linter.SARIF(diags, Version))
+		default:
+			errAndExit("ERROR: The format must be text, json or sarif.")
+		}
+		if len(diags) > 0 {
+			os.Exit(1)
 		}
 
 	} else {
@@ -124,11 +269,147 @@ func printVersion() string {
 }
 
 func printError(msg error) {
+	writeError(msg)
+	os.Exit(1)
+}
+
+// writeError writes msg on stderr as a JSON object
+func writeError(msg error) {
 	err := make(map[string]string)
-	err["error"] = msg.Error()
+	if pe, ok := msg.(*grammar.ParseError); ok {
+		err[pe.Kind] = "error"
+		if pe.IsWarning() {
+			err[pe.Kind] = pe.Severity
+		}
+		err["line"] = fmt.Sprintf("%d", pe.Line)
+		err["column"] = fmt.Sprintf("%d", pe.Column)
+		err["file_name"] = pe.FileName
+		err["msg"] = pe.Msg
+	} else {
+		err["error"] = msg.Error()
+	}
 	j, _ := json.Marshal(err)
-	os.Stderr.Write(j)
-	os.Exit(1)
+	os.Stderr.Write(append(j, '\n'))
+}
+
+// reportFormat returns the --format option, or "" when it is not given
+func reportFormat(arguments map[string]interface{}) string {
+	if format, ok := arguments["--format"].(string); ok {
+		return format
+	}
+	return ""
+}
+
+// reportParse reports the diagnostics recorded while parsing res and err,
+// the error found while parsing, using the --format option. They are written
+// on stderr, or to the --diagnostics file, as stdout holds the rules. Without
+// format warnings and errors are printed on stderr as they always were. Files
+// which could not be parsed, listed in yago.FileErrors, are all reported
+// and reportParse returns true. Any other error makes YaGo exit.
+func reportParse(res []*grammar.Parser, err error, arguments map[string]interface{}) bool {
+	format := reportFormat(arguments)
+	diagFile, _ := arguments["--diagnostics"].(string)
+	if diagFile != "" && format == "" {
+		format = "text"
+	}
+	fileErrs, partial := err.(yago.FileErrors)
+	if err != nil && !partial {
+		fileErrs = yago.FileErrors{{Err: err}}
+	}
+	if format == "" {
+		for _, p := range res {
+			for _, d := range p.Diagnostics {
+				if d.IsWarning() {
+					writeError(d)
+				}
+			}
+		}
+		if !partial {
+			checkErr(err)
+			return false
+		}
+		for _, fe := range fileErrs {
+			writeError(fe.Err)
+		}
+		return true
+	}
+
+	var diags []*grammar.ParseError
+	for _, p := range res {
+		diags = append(diags, p.Diagnostics...)
+	}
+	for _, fe := range fileErrs {
+		pe, ok := fe.Err.(*grammar.ParseError)
+		if !ok {
+			if !partial {
+				printError(fe.Err)
+			}
+			pe = &grammar.ParseError{Kind: "file", FileName: fe.FileName, Msg: fe.Err.Error()}
+		}
+		diags = append(diags, pe)
+	}
+	if diagFile != "" {
+		f, ferr := os.Create(diagFile)
+		if ferr != nil {
+			errAndExit("ERROR: " + ferr.Error())
+		}
+		writeDiagnostics(f, diags, format)
+		checkErr(f.Close())
+	} else if len(diags) > 0 || format == "sarif" {
+		writeDiagnostics(os.Stderr, diags, format)
+	}
+	if err != nil && !partial {
+		os.Exit(1)
+	}
+	return partial
+}
+
+// checkStatus returns the exit status of check: 1 when diags holds errors,
+// 0 when it is empty or only holds warnings
+func checkStatus(diags []*grammar.ParseError) int {
+	for _, d := range diags {
+		if !d.IsWarning() {
+			return 1
+		}
+	}
+	return 0
+}
+
+// workers returns the --workers option
+func workers(arguments map[string]interface{}) int {
+	n, err := strconv.Atoi(arguments["--workers"].(string))
+	if err != nil || n < 0 {
+		errAndExit("ERROR: The number of workers must be a positive number.")
+	}
+	return n
+}
+
+// writeDiagnostics writes diags to w as text, json or sarif
+func writeDiagnostics(w io.Writer, diags []*grammar.ParseError, format string) {
+	switch format {
+	case "text":
+		for _, d := range diags {
+			io.WriteString(w, d.Error()+"\n")
+		}
+	case "json":
+		if diags == nil {
+			diags = []*grammar.ParseError{}
+		}
+		writeJSON(w, diags)
+	case "sarif":
+		log := sarif.New(Version)
+		log.AddParseErrors(diags)
+		writeJSON(w, log)
+	default:
+		errAndExit("ERROR: The format must be text, json or sarif.")
+	}
+}
+
+// writeJSON writes v to w as indented JSON
+func writeJSON(w io.Writer, v interface{}) {
+	j, err := json.MarshalIndent(v, "", "  ")
+	checkErr(err)
+	w.Write(append(j, '\n'))
 }
 
 func errAndExit(msg string) {
@@ -138,7 +419,6 @@ func errAndExit(msg string) {
 
 func checkErr(err error) {
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		printError(err)
 	}
 }
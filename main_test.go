@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Yara-Rules/yago/yago"
+)
+
+func TestCheckStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name, src string
+		status    int
+	}{
+		{"valid", "rule a { condition: true }\n", 0},
+		{"warning", "import \"pe\"\nimport \"pe\"\nrule a { condition: pe.is_dll() }\n", 0},
+		{"semantic", "rule a { condition: $a }\n", 1},
+		{"syntax", "rule a { condition: }\n", 1},
+	}
+	for _, test := range tests {
+		fileName := filepath.Join(dir, test.name+".yar")
+		if err := ioutil.WriteFile(fileName, []byte(test.src), 0644); err != nil {
+			t.Fatal(err)
+		}
+		diags, err := yago.CheckFile(fileName)
+		if err != nil {
+			t.Fatalf("%s: CheckFile: %v", test.name, err)
+		}
+		if status := checkStatus(diags); status != test.status {
+			t.Errorf("%s: status %d with %v, want %d", test.name, status, diags, test.status)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package sarif writes diagnostics in the Static Analysis Results
+// Interchange Format (SARIF) 2.1.0, understood by code scanning tools.
+package sarif
+
+import (
+	"path/filepath"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// Schema and version of the SARIF logs written
+const (
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	Version = "2.1.0"
+)
+
+// InformationURI is the home page of YaGo, reported as the tool of the logs
+const InformationURI = "https://github.com/Yara-Rules/yago"
+
+// Log is a SARIF log holding the results of a single run of a tool
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the tool that was run and the results it found
+type Run struct {
+	Tool       Tool     `json:"tool"`
+	ColumnKind string   `json:"columnKind"`
+	Results    []Result `json:"results"`
+}
+
+// Tool describes the tool that produced the results
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the name, version and rules of a tool
+type Driver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules,omitempty"`
+}
+
+// ReportingDescriptor describes a kind of result, such as a lint check
+type ReportingDescriptor struct {
+	ID               string   `json:"id"`
+	ShortDescription *Message `json:"shortDescription,omitempty"`
+}
+
+// Result is a single problem found by the tool
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+}
+
+// Message is the text of a result or a description
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location is where a result was found
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a file and a region of it
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation is the URI of a file, relative to where the tool was run
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a position in a file. Lines and columns start at 1, columns
+// count characters.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// New returns an empty log for the given version of YaGo
+func New(version string) *Log {
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs: []Run{{
+			Tool:       Tool{Driver: Driver{Name: "YaGo", Version: version, InformationURI: InformationURI}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    []Result{},
+		}},
+	}
+}
+
+// AddRule describes the results reported with id. Without a description,
+// the ones of the errors reported by the parser are used. Rules already
+// described are ignored.
+func (l *Log) AddRule(id, description string) {
+	driver := &l.Runs[0].Tool.Driver
+	for _, rule := range driver.Rules {
+		if rule.ID == id {
+			return
+		}
+	}
+	rule := ReportingDescriptor{ID: id}
+	if description == "" {
+		description = descriptions[id]
+	}
+	if description != "" {
+		rule.ShortDescription = &Message{Text: description}
+	}
+	driver.Rules = append(driver.Rules, rule)
+}
+
+// AddResult records a problem found in fileName. level is one of error,
+// warning or note, column is in characters. Results without a line have
+// no region.
+func (l *Log) AddResult(ruleID, level, msg, fileName string, line, column int) {
+	res := Result{RuleID: ruleID, Level: level, Message: Message{Text: msg}}
+	if fileName != "" {
+		loc := Location{PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: filepath.ToSlash(fileName)},
+		}}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &Region{StartLine: line, StartColumn: column}
+		}
+		res.Locations = append(res.Locations, loc)
+	}
+	l.Runs[0].Results = append(l.Runs[0].Results, res)
+}
+
+// descriptions of the kinds and codes of the errors reported by the parser
+var descriptions = map[string]string{
+	grammar.LexicalError:     "The rule file contains invalid characters or unterminated tokens",
+	grammar.SyntacticalError: "The rule file does not follow the Yara grammar",
+	grammar.IncludeError:     "An included file cannot be found or includes itself",
+	grammar.SemanticError:    "The rule would not compile",
+	grammar.UndefinedString:  "Strings used in a condition must be defined",
+	grammar.UnusedString:     "Strings must be used in the condition",
+	grammar.UndefinedRule:    "Identifiers used in a condition must be defined",
+	grammar.UndefinedModule:  "Modules must be imported before being used",
+	grammar.RuleOrder:        "Rules must be defined before being referenced",
+	grammar.LoopVariable:     "Loop variables must be unique, used inside their loop and match the values iterated",
+	grammar.LoopNesting:      "Loops must not be nested too deep",
+	grammar.DuplicateImport:  "Modules should be imported once",
+}
+
+// AddParseErrors records errors found while parsing or validating rules.
+// Their rule id is the code of the error, or its kind when it has none.
+func (l *Log) AddParseErrors(errs []*grammar.ParseError) {
+	for _, err := range errs {
+		id := err.Kind
+		if err.Code != "" {
+			id = err.Code
+		}
+		level := "error"
+		if err.IsWarning() {
+			level = "warning"
+		}
+		l.AddRule(id, "")
+		l.AddResult(id, level, err.Msg, err.FileName, err.Line, err.RuneColumn)
+	}
+}
@@ -0,0 +1,15 @@
+package sarif
+
+import (
+	"testing"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+func TestDescriptions(t *testing.T) {
+	for _, id := range append(append([]string{}, grammar.Kinds...), grammar.Codes...) {
+		if descriptions[id] == "" {
+			t.Errorf("no description for %s", id)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package yago
+
+import (
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// CheckFile parses fileName, following its includes, and validates its
+// rules. It returns the syntax errors and the semantic problems found.
+func CheckFile(fileName string) ([]*grammar.ParseError, error) {
+	p := NewParser(fileName)
+	p.SetLogLevel(DEBUG_LEVEL)
+	p.SetRecovery(true)
+	if err := p.ParseFile(fileName); err != nil {
+		return nil, err
+	}
+	return append(p.Diagnostics, grammar.Validate(p.Imports, p.Rules)...), nil
+}
@@ -0,0 +1,63 @@
+package yago
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yara-Rules/yago/format"
+)
+
+// RuleFiles expands paths into the list of Yara files to process. Files are
+// returned as given and directories are walked looking for .yar and .yara
+// files.
+func RuleFiles(paths []string) ([]string, error) {
+	var fileList []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			fileList = append(fileList, p)
+			continue
+		}
+		err = filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if !info.IsDir() && (ext == ".yar" || ext == ".yara") {
+				fileList = append(fileList, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return fileList, nil
+}
+
+// FormatFile formats a Yara rule file. It returns the formatted source and
+// whether it differs from the file contents. When write is set, changed
+// files are rewritten in place.
+func FormatFile(fileName string, opts format.Options, write bool) ([]byte, bool, error) {
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, false, err
+	}
+	res, err := format.Source(filepath.Base(fileName), src, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	changed := !bytes.Equal(src, res)
+	if write && changed {
+		if err := writeFile(fileName, string(res), true); err != nil {
+			return nil, false, err
+		}
+	}
+	return res, changed, nil
+}
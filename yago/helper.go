@@ -1,22 +1,40 @@
 package yago
 
 import (
-	"encoding/json"
-	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
 )
 
-func checkErr(err error) {
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+// parseFile reads and parses a single Yara rule file, named by its path as
+// given like the files it includes
+func parseFile(fileName string) (*grammar.Parser, error) {
+	p := NewParser(fileName)
+	p.SetLogLevel(DEBUG_LEVEL)
+	if err := p.ParseFile(fileName); err != nil {
+		return nil, err
 	}
+	return p, nil
 }
 
-func printError(msg error) {
-	err := make(map[string]string)
-	err["error"] = msg.Error()
-	j, _ := json.Marshal(err)
-	os.Stderr.Write(j)
-	os.Exit(1)
+// writeFile writes content into fileName unless it exists and overwrite is not set
+func writeFile(fileName, content string, overwrite bool) error {
+	if !overwrite {
+		if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+			return nil
+		}
+	}
+	return ioutil.WriteFile(fileName, []byte(content), 0644)
+}
+
+// outputPath returns where the rules read from fileName are written in
+// outputDir, keeping the directories of relative paths
+func outputPath(outputDir, fileName string) string {
+	if filepath.IsAbs(fileName) || strings.HasPrefix(filepath.Clean(fileName), "..") {
+		fileName = filepath.Base(fileName)
+	}
+	return filepath.Join(outputDir, fileName)
 }
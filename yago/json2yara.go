@@ -1,18 +1,14 @@
 package yago
 
 import (
-	"fmt"
-
+	"github.com/Yara-Rules/yago/format"
 	"github.com/Yara-Rules/yago/grammar"
 )
 
-type jsonCloak struct {
-	Ruleset []*grammar.Parser
-}
-
 type unify struct {
-	imports []string
-	rules   []grammar.RuleDef
+	imports  []string
+	rules    []grammar.RuleDef
+	comments *grammar.Comments
 }
 
 func (u *unify) addImport(imp string) {
@@ -42,61 +38,18 @@ func (u *unify) addRule(rule grammar.RuleDef) {
 
 }
 
-func (u *unify) String() string {
-	r := ""
-	for _, imp := range u.imports {
-		r += fmt.Sprintf("import \"%s\"\n", imp)
+// addComments keeps the comments of the rulesets, in order
+func (u *unify) addComments(c *grammar.Comments) {
+	if c == nil {
+		return
 	}
-
-	if len(u.imports) > 0 {
-		r += fmt.Sprintf("\n")
+	if u.comments == nil {
+		u.comments = &grammar.Comments{}
 	}
+	u.comments.Leading = append(u.comments.Leading, c.Leading...)
+	u.comments.Trailing = append(u.comments.Trailing, c.Trailing...)
+}
 
-	for _, rule := range u.rules {
-		if rule.Private {
-			r += fmt.Sprintf("private ")
-		}
-		if rule.Global {
-			r += fmt.Sprintf("global ")
-		}
-
-		r += fmt.Sprintf("rule %s", rule.Name)
-		if len(rule.Tags) > 0 {
-			r += " :"
-			for _, tag := range rule.Tags {
-				r += fmt.Sprintf(" %s", tag)
-			}
-			r += " {\n"
-		} else {
-			r += fmt.Sprintf(" {\n")
-		}
-		if len(rule.Meta) > 0 {
-			r += fmt.Sprintf("\tmeta:\n")
-			for k, v := range rule.Meta {
-				r += fmt.Sprintf("\t\t%s = \"%s\"\n", k, v)
-			}
-		}
-		if len(rule.Strings) > 0 {
-			r += fmt.Sprintf("\tstrings:\n")
-			for _, str := range rule.Strings {
-				if str.Typ == grammar.StringString {
-					r += fmt.Sprintf("\t\t%s = \"%s\"", str.Name, str.Value)
-					if len(str.Modifiers) > 0 {
-						for _, m := range str.Modifiers {
-							r += fmt.Sprintf(" %s", m)
-						}
-					}
-				} else if str.Typ == grammar.StringRegex {
-					r += fmt.Sprintf("\t\t%s = %s", str.Name, str.Value)
-				} else if str.Typ == grammar.StringHex {
-					r += fmt.Sprintf("\t\t%s = %s", str.Name, str.Value)
-				}
-				r += fmt.Sprintf("\n")
-			}
-		}
-		r += fmt.Sprintf("\tcondition:\n")
-		r += fmt.Sprintf("\t\t%s\n", rule.Condition)
-		r += fmt.Sprintf("}\n\n")
-	}
-	return r
+func (u *unify) String() string {
+	return format.File(&grammar.Parser{Imports: u.imports, Rules: u.rules, Comments: u.comments}, format.DefaultOptions)
 }
@@ -0,0 +1,32 @@
+package yago
+
+import (
+	"io/ioutil"
+
+	"github.com/Yara-Rules/yago/lint"
+)
+
+// LintFile parses fileName, following its includes, and runs the checks of
+// l on its rules. Syntax errors are returned as diagnostics as well.
+func LintFile(fileName string, l *lint.Linter) ([]lint.Diagnostic, error) {
+	p := NewParser(fileName)
+	p.SetLogLevel(DEBUG_LEVEL)
+	p.SetRecovery(true)
+	if err := p.ParseFile(fileName); err != nil {
+		return nil, err
+	}
+	var diags []lint.Diagnostic
+	for _, err := range p.Diagnostics {
+		diags = append(diags, lint.FromParseError(err))
+	}
+	return append(diags, l.Lint(p.Rules, readSource)...), nil
+}
+
+// readSource returns the contents of fileName, or nil if it cannot be read
+func readSource(fileName string) []byte {
+	src, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil
+	}
+	return src
+}
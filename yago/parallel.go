@@ -0,0 +1,81 @@
+package yago
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// FileError is the error found while processing a file
+type FileError struct {
+	FileName string
+	Err      error
+}
+
+// Error returns the error of the file. Parse errors already name the file.
+func (e *FileError) Error() string {
+	if _, ok := e.Err.(*grammar.ParseError); ok {
+		return e.Err.Error()
+	}
+	return e.FileName + ": " + e.Err.Error()
+}
+
+// FileErrors are the errors of the files which could not be processed,
+// sorted by file name
+type FileErrors []*FileError
+
+// Error returns the errors of the files, one per line
+func (e FileErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// parseResult is a parsed file or the error found parsing it
+type parseResult struct {
+	p   *grammar.Parser
+	err error
+}
+
+// parseFiles runs parse on fileNames with workers goroutines, one per CPU
+// when workers is 0 or less. Results are in the order of fileNames. It
+// stops handing files out once ctx is done and returns ctx.Err().
+func parseFiles(ctx context.Context, fileNames []string, workers int, parse func(string) (*grammar.Parser, error)) ([]parseResult, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	results := make([]parseResult, len(fileNames))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				p, err := parse(fileNames[i])
+				results[i] = parseResult{p: p, err: err}
+			}
+		}()
+	}
+
+feed:
+	for i := range fileNames {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
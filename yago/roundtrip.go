@@ -0,0 +1,81 @@
+package yago
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Yara-Rules/yago/format"
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// RoundTrip parses fileName, converts it to JSON and back to Yara the same
+// way inputFile does, and parses the result again. It returns the names of
+// the rules whose model is not the same after the round trip, import when
+// the imports are not and comments when the comments of the file are not.
+func RoundTrip(fileName string) ([]string, error) {
+	orig, err := parseFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := json.Marshal(orig)
+	if err != nil {
+		return nil, err
+	}
+	decoded := &grammar.Parser{}
+	if err := json.Unmarshal(j, decoded); err != nil {
+		return nil, err
+	}
+	DropConditionAST([]*grammar.Parser{decoded})
+
+	back := NewParser(orig.Name)
+	back.SetLogLevel(DEBUG_LEVEL)
+	if err := back.Parse(format.File(decoded, format.DefaultOptions)); err != nil {
+		return nil, fmt.Errorf("%s: converted rules do not parse: %s", orig.Name, err)
+	}
+
+	var diff []string
+	if !reflect.DeepEqual(orig.Imports, back.Imports) {
+		diff = append(diff, "import")
+	}
+	if !reflect.DeepEqual(orig.Comments, back.Comments) {
+		diff = append(diff, "comments")
+	}
+	for i, rule := range orig.Rules {
+		if i >= len(back.Rules) || !sameRule(rule, back.Rules[i]) {
+			diff = append(diff, rule.Name)
+		}
+	}
+	return diff, nil
+}
+
+// sameRule compares two rules leaving aside where they were read from
+func sameRule(a, b grammar.RuleDef) bool {
+	return reflect.DeepEqual(withoutPositions(a), withoutPositions(b))
+}
+
+func withoutPositions(rule grammar.RuleDef) grammar.RuleDef {
+	rule.File, rule.Span, rule.ConditionSpan = "", nil, nil
+	rule.ConditionAST.Walk(func(e *grammar.Expr) bool {
+		e.Pos = nil
+		return true
+	})
+	if rule.Meta != nil {
+		meta := make(grammar.MetaList, len(rule.Meta))
+		for i, m := range rule.Meta {
+			m.Line, m.Span = 0, nil
+			meta[i] = m
+		}
+		rule.Meta = meta
+	}
+	if rule.Strings != nil {
+		strs := make([]grammar.StringDef, len(rule.Strings))
+		for i, str := range rule.Strings {
+			str.Span = nil
+			strs[i] = str
+		}
+		rule.Strings = strs
+	}
+	return rule
+}
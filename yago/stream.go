@@ -0,0 +1,62 @@
+package yago
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// DecodeRuleset reads rules converted to JSON in the {"ruleset": [...]}
+// form, calling onRuleset with each element of the list as soon as it is
+// decoded, so a single one is held in memory at a time. Decoding stops at
+// the first error returned by onRuleset, which DecodeRuleset returns.
+func DecodeRuleset(r io.Reader, onRuleset func(*grammar.Parser) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if key, _ := tok.(string); !strings.EqualFold(key, "ruleset") {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			p := &grammar.Parser{}
+			if err := dec.Decode(p); err != nil {
+				return err
+			}
+			if err := onRuleset(p); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token of dec, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("Expected %s found %v", delim, tok)
+	}
+	return nil
+}
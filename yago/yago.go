@@ -2,23 +2,17 @@ package yago
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"sort"
 
+	"github.com/Yara-Rules/yago/format"
 	"github.com/Yara-Rules/yago/grammar"
 )
 
 const (
-	MULTILINE   = `\s*/\*([^*]|\*+[^*/])*\*+/\s*`
-	INLINE      = `(?m)\s*//.*[\n\r][\n\r]?`
-	BLANKS      = `(?m)\s+$`
-	QUOTES      = `"`
 	MAXBUFF     = 1024 * 1024 // If needed Go will take it form RAM.
 	DEBUG_LEVEL = "INFO"
 )
@@ -27,98 +21,146 @@ func NewParser(name string) *grammar.Parser {
 	return grammar.New(name)
 }
 
-func ProcessFile(fileName string) []*grammar.Parser {
-	file, err := ioutil.ReadFile(fileName)
-	checkErr(err)
-
-	p := NewParser(path.Base(fileName))
-	p.SetLogLevel(DEBUG_LEVEL)
-	p.Parse(string(file))
+// ProcessFile parses a Yara rule file
+func ProcessFile(fileName string) ([]*grammar.Parser, error) {
+	p, err := parseFile(fileName)
+	if err != nil {
+		return nil, err
+	}
 
 	var res []*grammar.Parser
 	res = append(res, p)
-	return res
+	return res, nil
 }
 
-func ProcessDir(dirName string) []*grammar.Parser {
-	var res []*grammar.Parser
+// ProcessDir parses all files inside a directory tree, a file per CPU at
+// once. See ProcessDirContext.
+func ProcessDir(dirName string) ([]*grammar.Parser, error) {
+	return ProcessDirContext(context.Background(), dirName, 0)
+}
+
+// ProcessDirContext parses all files inside a directory tree with workers
+// files parsed at once, one per CPU when workers is 0 or less. Rulesets are
+// sorted by path. Files which cannot be parsed do not stop the others: the
+// rulesets of the other files are returned along with FileErrors. Parsing
+// stops when ctx is done, returning ctx.Err().
+func ProcessDirContext(ctx context.Context, dirName string, workers int) ([]*grammar.Parser, error) {
 	fileList := []string{}
-	filepath.Walk(dirName, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(dirName, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
 		if !info.IsDir() {
 			fileList = append(fileList, path)
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(fileList)
 
-	for _, filePath := range fileList {
-		file, err := ioutil.ReadFile(filePath)
-		checkErr(err)
-
-		p := NewParser(path.Base(filePath))
-		p.SetLogLevel(DEBUG_LEVEL)
-		p.Parse(string(file))
-		res = append(res, p)
+	results, err := parseFiles(ctx, fileList, workers, parseFile)
+	if err != nil {
+		return nil, err
 	}
-	return res
+	var res []*grammar.Parser
+	var errs FileErrors
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, &FileError{FileName: fileList[i], Err: r.err})
+			continue
+		}
+		res = append(res, r.p)
+	}
+	if errs != nil {
+		return res, errs
+	}
+	return res, nil
 }
 
-func ProcessIndex(indexFile, cwd string) []*grammar.Parser {
-	var res []*grammar.Parser
-	file, err := ioutil.ReadFile(indexFile)
-	checkErr(err)
-
-	re := regexp.MustCompile(MULTILINE)
-	index := re.ReplaceAllString(string(file), "")
-
-	re = regexp.MustCompile(INLINE)
-	index = re.ReplaceAllString(index, "")
-	index = re.ReplaceAllString(index, "")
-
-	re = regexp.MustCompile(BLANKS)
-	index = re.ReplaceAllString(index, "")
-
-	re = regexp.MustCompile(QUOTES)
-	index = re.ReplaceAllString(index, "")
-
-	lines := strings.Split(index, "\n")
-	for _, line := range lines {
-		ruleFile := strings.Split(line, " ")
-		if len(ruleFile) == 2 {
-			rulePath := path.Join(cwd, ruleFile[1])
-			if _, err := os.Stat(rulePath); err == nil {
-
-				file, err := ioutil.ReadFile(rulePath)
-				checkErr(err)
-
-				p := NewParser(path.Base(rulePath))
-				p.SetLogLevel(DEBUG_LEVEL)
-				p.Parse(string(file))
-				res = append(res, p)
-			} else {
-				os.Stdout.WriteString(fmt.Sprintf("WARNING: Rule file %s does not exist. Check the cwd argument.\n", rulePath))
-			}
+// ProcessIndex parses an index file and every file it includes into a
+// single ruleset. See ProcessIndexContext.
+func ProcessIndex(indexFile, cwd string) ([]*grammar.Parser, error) {
+	return ProcessIndexContext(context.Background(), indexFile, cwd, 0)
+}
+
+// ProcessIndexContext parses an index file and every file it includes into
+// a single ruleset. Included files are looked for next to the file including
+// them and then in cwd. The files the index includes are parsed beforehand
+// with workers files parsed at once, one per CPU when workers is 0 or less,
+// and their rules added in the order of the include directives. Included
+// files which cannot be parsed are left out and returned as FileErrors
+// along with the ruleset. Parsing stops when ctx is done, returning
+// ctx.Err().
+func ProcessIndexContext(ctx context.Context, indexFile, cwd string, workers int) ([]*grammar.Parser, error) {
+	p := NewParser(indexFile)
+	p.SetLogLevel(DEBUG_LEVEL)
+	p.SetIncludePaths(cwd)
+
+	included, err := p.IncludedFiles(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	results, err := parseFiles(ctx, included, workers, func(fileName string) (*grammar.Parser, error) {
+		sub := NewParser(fileName)
+		sub.SetLogLevel(DEBUG_LEVEL)
+		sub.SetIncludePaths(cwd)
+		if err := sub.ParseFile(fileName); err != nil {
+			return nil, err
+		}
+		return sub, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	parsed := map[string]parseResult{}
+	for i, fileName := range included {
+		parsed[fileName] = results[i]
+	}
+	var errs FileErrors
+	p.SetIncludeLoader(func(fileName string) (*grammar.Parser, error) {
+		r := parsed[fileName]
+		delete(parsed, fileName) // a file included twice is parsed again
+		if r.err != nil {
+			errs = append(errs, &FileError{FileName: fileName, Err: r.err})
+			return NewParser(fileName), nil // without its rules
 		}
+		return r.p, nil
+	})
+
+	if err := p.ParseFile(indexFile); err != nil {
+		return nil, err
+	}
+	if errs != nil {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].FileName < errs[j].FileName })
+		return []*grammar.Parser{p}, errs
 	}
-	return res
+	return []*grammar.Parser{p}, nil
 }
 
-func ProcessInputFile(inputFile string, validJSON bool) []*grammar.Parser {
+// ProcessInputFile loads rules previously converted to JSON
+func ProcessInputFile(inputFile string, validJSON bool) ([]*grammar.Parser, error) {
 	var res []*grammar.Parser
 	if validJSON {
-		file, err := ioutil.ReadFile(inputFile)
-		checkErr(err)
-
-		jc := &jsonCloak{}
-		err = json.Unmarshal(file, jc)
+		file, err := os.Open(inputFile)
 		if err != nil {
-			printError(err)
+			return nil, err
 		}
-		for _, r := range jc.Ruleset {
-			res = append(res, r)
+		defer file.Close()
+
+		err = DecodeRuleset(file, func(p *grammar.Parser) error {
+			res = append(res, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
 	} else {
 		file, err := os.Open(inputFile)
-		checkErr(err)
+		if err != nil {
+			return nil, err
+		}
 		defer file.Close()
 
 		var buff []byte
@@ -131,12 +173,15 @@ func ProcessInputFile(inputFile string, validJSON bool) []*grammar.Parser {
 			rules = &grammar.Parser{}
 			err = json.Unmarshal(scanner.Bytes(), rules)
 			if err != nil {
-				printError(err)
+				return nil, err
 			}
 			res = append(res, rules)
 		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
 	}
-	return res
+	return res, nil
 }
 
 func UnifyRules(rules []*grammar.Parser) unify {
@@ -149,53 +194,59 @@ func UnifyRules(rules []*grammar.Parser) unify {
 		for _, r := range rule.Rules {
 			ruleSet.addRule(r)
 		}
+		ruleSet.addComments(rule.Comments)
 	}
 	return ruleSet
 }
 
-func GenerateOutputFromYara(res []*grammar.Parser, validJSON bool) {
+// DropConditionAST removes the condition expression trees so they are left
+// out of the JSON output
+func DropConditionAST(res []*grammar.Parser) {
+	for _, p := range res {
+		for i := range p.Rules {
+			p.Rules[i].ConditionAST = nil
+		}
+	}
+}
+
+// GenerateOutputFromYara prints the parsed rules as JSON on stdout
+func GenerateOutputFromYara(res []*grammar.Parser, validJSON bool) error {
 	if validJSON == true {
 		ruleset := map[string][]*grammar.Parser{"ruleset": res}
 		j, err := json.Marshal(ruleset)
-		if err == nil {
-			os.Stdout.Write(j)
-		} else {
-			printError(err)
+		if err != nil {
+			return err
 		}
+		os.Stdout.Write(j)
 	} else {
 		for _, r := range res {
 			j, err := json.Marshal(r)
-			if err == nil {
-				os.Stdout.Write(j)
-				os.Stdout.WriteString("\n")
-			} else {
-				printError(err)
+			if err != nil {
+				return err
 			}
+			os.Stdout.Write(j)
+			os.Stdout.WriteString("\n")
 		}
 	}
+	return nil
 }
 
-func GenerateOutputToYaraDir(rules []*grammar.Parser, outputDir string, overwrite bool) {
+// GenerateOutputToYaraDir writes each rule file into outputDir
+func GenerateOutputToYaraDir(rules []*grammar.Parser, outputDir string, overwrite bool) error {
 	for _, rule := range rules {
-		savePath := path.Join(outputDir, rule.Name)
-		ruleStr := fmt.Sprintf("%s", rule.String())
-		if overwrite {
-			err := ioutil.WriteFile(savePath, []byte(ruleStr), 0644)
-			checkErr(err)
-		} else if _, err := os.Stat(savePath); os.IsNotExist(err) {
-			err := ioutil.WriteFile(savePath, []byte(ruleStr), 0644)
-			checkErr(err)
+		savePath := outputPath(outputDir, rule.Name)
+		if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
+			return err
+		}
+		ruleStr := format.File(rule, format.DefaultOptions)
+		if err := writeFile(savePath, ruleStr, overwrite); err != nil {
+			return err
 		}
 	}
+	return nil
 }
 
-func GenerateOutputToYaraFile(rule unify, outputFile string, overwrite bool) {
-	ruleStr := fmt.Sprintf("%s", rule.String())
-	if overwrite {
-		err := ioutil.WriteFile(outputFile, []byte(ruleStr), 0644)
-		checkErr(err)
-	} else if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		err := ioutil.WriteFile(outputFile, []byte(ruleStr), 0644)
-		checkErr(err)
-	}
+// GenerateOutputToYaraFile writes the unified rules into outputFile
+func GenerateOutputToYaraFile(rule unify, outputFile string, overwrite bool) error {
+	return writeFile(outputFile, rule.String(), overwrite)
 }
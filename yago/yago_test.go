@@ -0,0 +1,128 @@
+package yago
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Yara-Rules/yago/grammar"
+)
+
+// writeFiles writes files, by name, to a new directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "yago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func ruleNames(parsers []*grammar.Parser) []string {
+	var names []string
+	for _, p := range parsers {
+		for _, rule := range p.Rules {
+			names = append(names, rule.File+":"+rule.Name)
+		}
+	}
+	return names
+}
+
+func TestProcessIndexSequentialAndParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"distinct", map[string]string{
+			"index.yar": "include \"a.yar\"\ninclude \"b.yar\"\n",
+			"a.yar":     "rule a { condition: true }\n",
+			"b.yar":     "rule b { condition: a }\n",
+		}, ""},
+		{"duplicate", map[string]string{
+			"index.yar": "include \"a.yar\"\ninclude \"b.yar\"\n",
+			"a.yar":     "rule dup { condition: true }\n",
+			"b.yar":     "rule b { condition: true }\n\nrule dup { condition: false }\n",
+		}, "b.yar:3:1: Rule dup alredy defined."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeFiles(t, test.files)
+			defer os.RemoveAll(dir)
+			index := filepath.Join(dir, "index.yar")
+
+			seq := NewParser(index)
+			seqErr := seq.ParseFile(index)
+			res, parErr := ProcessIndexContext(context.Background(), index, "", 4)
+			if !reflect.DeepEqual(seqErr, parErr) {
+				t.Fatalf("errors differ: sequential %v, parallel %v", seqErr, parErr)
+			}
+			if seqErr != nil || test.wantErr != "" {
+				if seqErr == nil || !strings.HasSuffix(seqErr.Error(), test.wantErr) {
+					t.Errorf("error %v, want %s", seqErr, test.wantErr)
+				}
+				return
+			}
+			if seqNames, parNames := ruleNames([]*grammar.Parser{seq}), ruleNames(res); !reflect.DeepEqual(seqNames, parNames) {
+				t.Errorf("rules differ: sequential %v, parallel %v", seqNames, parNames)
+			}
+		})
+	}
+}
+
+func TestProcessDirFileNames(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"index.yar": "include \"b.yar\"\n",
+		"b.yar":     "rule b { condition: true }\n",
+	})
+	defer os.RemoveAll(dir)
+	for _, sub := range []string{"x", "y"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+		src := []byte("rule " + sub + " { condition: true }\n")
+		if err := ioutil.WriteFile(filepath.Join(dir, sub, "rules.yar"), src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res, err := ProcessDirContext(context.Background(), dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "b.yar") + ":b",
+		filepath.Join(dir, "b.yar") + ":b", // included by index.yar
+		filepath.Join(dir, "x", "rules.yar") + ":x",
+		filepath.Join(dir, "y", "rules.yar") + ":y",
+	}
+	if got := ruleNames(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("rules %v, want %v", got, want)
+	}
+	if name := filepath.Join(dir, "index.yar"); res[1].Name != name {
+		t.Errorf("ruleset name %s, want %s", res[1].Name, name)
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"rules.yar", "out/rules.yar"},
+		{"x/rules.yar", "out/x/rules.yar"},
+		{"/abs/rules.yar", "out/rules.yar"},
+		{"../up/rules.yar", "out/rules.yar"},
+	}
+	for _, test := range tests {
+		if got := outputPath("out", test.name); got != filepath.FromSlash(test.want) {
+			t.Errorf("outputPath(%q) = %s, want %s", test.name, got, test.want)
+		}
+	}
+}